@@ -15,6 +15,11 @@ type Event interface {
 }
 
 // Resize is an event that happens when the environment changes the size of its drawing area.
+//
+// The environment only keeps the content of the drawing area where it overlaps with the
+// previous one, a newly exposed part of it is blank. Drawing functions sent after receiving
+// the event draw onto the resized drawing area, so this is the time to repaint, or to lay
+// out and repaint, everything that got cut off or newly exposed.
 type Resize struct {
 	image.Rectangle
 }
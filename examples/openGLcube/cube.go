@@ -46,6 +46,11 @@ var camera		  mgl32.Mat4
 var cameraUniform int32
 
 func CubeInit() {
+	// the window initializes its own bindings only
+	if err := gl.Init(); err != nil {
+		panic(err)
+	}
+
 	var err error
 	program, err = newProgram(vertexShader, fragmentShader)
 	if err != nil {
@@ -9,6 +9,8 @@
 //
 //	- Click on colored buttons on the right to change cube background color
 //	- Scroll with mouse wheel to zoom the cube in and out
+//	- Pinch on the trackpad to zoom too
+//	- Press escape to quit
 //
 
 package main
@@ -54,16 +56,23 @@ func run() {
 		w.Draw() <- drawButton(i)
 	}
 
-	w.GL() <- CubeInit // send it to GL chanel so we have gl context in later calls
-	w.GL() <- CubeDraw // GL calls in CubeDraw function
+	w.OnGLInit(CubeInit) // runs on the GL thread, where the gl context is current
+	w.OnFrame(func(time.Duration) { CubeDraw() }) // GL calls in CubeDraw function, every frame
+
+	events := win.NewGestureRecognizer().Filter(w.Events())
 
 	loop:
 	for {
 		select {
-		case event, _ := <-w.Events():
+		case event, _ := <-events:
 			switch event := event.(type) {
-			case win.WiClose, win.KbDown:
+			case win.WiClose:
 				break loop
+			case win.KbDown:
+				// not on any key, Ctrl is held down for pinching
+				if event.Key == win.KeyEscape {
+					break loop
+				}
 			case win.MoDown:
 				if event.Point.X > windowWidth - rectWidth {
 					colorIndex := uint8(event.Point.Y/rectHeight)
@@ -71,14 +80,11 @@ func run() {
 				}
 			case win.MoScroll:
 				CubeZoomLevel += float32(event.Point.Y)*0.05
-				if CubeZoomLevel > 3 {
-					CubeZoomLevel = 3
-				} else if CubeZoomLevel < 0.75 {
-					CubeZoomLevel = 0.75
-				}
+				clampZoom()
+			case win.GesturePinch:
+				CubeZoomLevel /= float32(event.Scale) // a larger level is further away
+				clampZoom()
 			}
-		default:
-			w.GL() <- CubeDraw
 		}
 	}
 
@@ -94,6 +100,14 @@ func colors(index uint8) color.RGBA {
 	return color.RGBA{0, 0, 0, 255}
 }
 
+func clampZoom() {
+	if CubeZoomLevel > 3 {
+		CubeZoomLevel = 3
+	} else if CubeZoomLevel < 0.75 {
+		CubeZoomLevel = 0.75
+	}
+}
+
 func main() {
 	mainthread.Run(run)
 }
\ No newline at end of file
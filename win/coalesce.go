@@ -0,0 +1,79 @@
+package win
+
+import "github.com/bbeni/guiGL"
+
+// makeCoalescingEventsChan is like gui.MakeEventsChan, but while events are waiting for the
+// consumer, a mouse movement replaces the one of the same type still waiting, as long as
+// there is no other event in between. MoMoveRel events add up instead. Other events never
+// get dropped or reordered.
+func makeCoalescingEventsChan() (<-chan gui.Event, chan<- gui.Event) {
+	out, in := make(chan gui.Event), make(chan gui.Event)
+
+	go func() {
+		var queue []gui.Event
+
+		for {
+			x, ok := <-in
+			if !ok {
+				close(out)
+				return
+			}
+			queue = coalesce(queue, x)
+
+			for len(queue) > 0 {
+				select {
+				case out <- queue[0]:
+					queue = queue[1:]
+				case x, ok := <-in:
+					if !ok {
+						for _, x := range queue {
+							out <- x
+						}
+						close(out)
+						return
+					}
+					queue = coalesce(queue, x)
+				}
+			}
+		}
+	}()
+
+	return out, in
+}
+
+// coalesce appends e to the queue, merging it with a waiting mouse movement of the same type
+// among the mouse movements at the end of the queue.
+func coalesce(queue []gui.Event, e gui.Event) []gui.Event {
+	if !isMove(e) {
+		return append(queue, e)
+	}
+	for i := len(queue) - 1; i >= 0 && isMove(queue[i]); i-- {
+		switch q := queue[i].(type) {
+		case MoMove:
+			if _, ok := e.(MoMove); !ok {
+				continue
+			}
+		case MoMoveF:
+			if _, ok := e.(MoMoveF); !ok {
+				continue
+			}
+		case MoMoveRel:
+			r, ok := e.(MoMoveRel)
+			if !ok {
+				continue
+			}
+			e = MoMoveRel{q.Dx + r.Dx, q.Dy + r.Dy, r.Stamp}
+		}
+		queue = append(queue[:i], queue[i+1:]...)
+		break
+	}
+	return append(queue, e)
+}
+
+func isMove(e gui.Event) bool {
+	switch e.(type) {
+	case MoMove, MoMoveF, MoMoveRel:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package win
+
+import (
+	"image"
+	"reflect"
+	"testing"
+
+	"github.com/bbeni/guiGL"
+)
+
+func TestCoalesce(t *testing.T) {
+	move := func(x, y int) MoMove { return MoMove{Point: image.Pt(x, y)} }
+	rel := func(dx, dy float64) MoMoveRel { return MoMoveRel{Dx: dx, Dy: dy} }
+	down := MoDown{Point: image.Pt(1, 1), Button: ButtonLeft}
+
+	tests := []struct {
+		name   string
+		events []gui.Event
+		want   []gui.Event
+	}{
+		{
+			name:   "moves",
+			events: []gui.Event{move(1, 1), move(2, 2), move(3, 3)},
+			want:   []gui.Event{move(3, 3)},
+		},
+		{
+			name:   "relative moves add up",
+			events: []gui.Event{rel(1, 2), rel(3, 4)},
+			want:   []gui.Event{rel(4, 6)},
+		},
+		{
+			name:   "different moves",
+			events: []gui.Event{move(1, 1), rel(1, 1), move(2, 2), rel(2, 2)},
+			want:   []gui.Event{move(2, 2), rel(3, 3)},
+		},
+		{
+			name:   "not across other events",
+			events: []gui.Event{move(1, 1), down, move(2, 2), move(3, 3)},
+			want:   []gui.Event{move(1, 1), down, move(3, 3)},
+		},
+		{
+			name:   "other events stay",
+			events: []gui.Event{down, down, KbType{Rune: 'a'}},
+			want:   []gui.Event{down, down, KbType{Rune: 'a'}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var queue []gui.Event
+			for _, e := range tt.events {
+				queue = coalesce(queue, e)
+			}
+			if !reflect.DeepEqual(queue, tt.want) {
+				t.Errorf("got %v, want %v", queue, tt.want)
+			}
+		})
+	}
+}
+
+func TestCoalescingEventsChan(t *testing.T) {
+	out, in := makeCoalescingEventsChan()
+	events := []gui.Event{
+		MoMove{Point: image.Pt(1, 1)},
+		MoMove{Point: image.Pt(2, 2)},
+		KbType{Rune: 'a'},
+	}
+	for _, e := range events {
+		in <- e
+	}
+	close(in)
+
+	// the first move may or may not have been received before the second one came in
+	var got []gui.Event
+	for e := range out {
+		got = append(got, e)
+	}
+	if len(got) < 2 || !reflect.DeepEqual(got[len(got)-2:], events[1:]) {
+		t.Errorf("got %v, want the events ending in %v", got, events[1:])
+	}
+}
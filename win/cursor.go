@@ -0,0 +1,53 @@
+package win
+
+import "github.com/go-gl/glfw/v3.3/glfw"
+
+// StandardCursor is a cursor shape provided by the OS.
+type StandardCursor int
+
+// List of all standard cursors.
+const (
+	CursorArrow StandardCursor = iota
+	CursorHand
+	CursorIBeam
+	CursorCrosshair
+	CursorHResize
+	CursorVResize
+)
+
+var standardCursors = map[StandardCursor]glfw.StandardCursor{
+	CursorHand:      glfw.HandCursor,
+	CursorIBeam:     glfw.IBeamCursor,
+	CursorCrosshair: glfw.CrosshairCursor,
+	CursorHResize:   glfw.HResizeCursor,
+	CursorVResize:   glfw.VResizeCursor,
+}
+
+// SetStandardCursor sets the shape of the mouse cursor over the window, e.g. CursorIBeam over
+// a text field or CursorHResize over a divider that can be dragged. CursorArrow is the
+// default. It does nothing if the window is closed.
+func (w *Win) SetStandardCursor(shape StandardCursor) {
+	w.callMain(func() {
+		shape, ok := standardCursors[shape]
+		if !ok {
+			// the default arrow
+			w.w.SetCursor(nil)
+			return
+		}
+		c, ok := w.cursors[shape]
+		if !ok {
+			c = glfw.CreateStandardCursor(shape)
+			w.cursors[shape] = c
+		}
+		w.w.SetCursor(c)
+	})
+}
+
+// destroyCursors frees the cursors created for the window. It must be called on the main
+// thread.
+func (w *Win) destroyCursors() {
+	for shape, c := range w.cursors {
+		c.Destroy()
+		delete(w.cursors, shape)
+	}
+}
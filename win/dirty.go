@@ -0,0 +1,53 @@
+package win
+
+import "image"
+
+// maxDirtyRects is the number of separate rectangles dirtyRects keeps track of. Beyond that
+// they get merged into one, so a lot of tiny changes don't turn into a lot of uploads.
+const maxDirtyRects = 32
+
+// dirtyRects is the set of parts of the gui that changed since they were last put on the
+// screen. Rectangles that overlap or touch get merged, the others are kept apart, so two
+// small changes far from each other don't make everything in between get uploaded too.
+type dirtyRects []image.Rectangle
+
+// add adds r to the set.
+func (d *dirtyRects) add(r image.Rectangle) {
+	if r.Empty() {
+		return
+	}
+	rs := *d
+	for i := 0; i < len(rs); {
+		if !touch(rs[i], r) {
+			i++
+			continue
+		}
+		// r grew, so start over, it may touch the ones already checked now
+		r = r.Union(rs[i])
+		rs[i] = rs[len(rs)-1]
+		rs = rs[:len(rs)-1]
+		i = 0
+	}
+	rs = append(rs, r)
+	if len(rs) > maxDirtyRects {
+		for _, o := range rs[1:] {
+			rs[0] = rs[0].Union(o)
+		}
+		rs = rs[:1]
+	}
+	*d = rs
+}
+
+// touch tells whether a and b overlap or share an edge.
+func touch(a, b image.Rectangle) bool {
+	return a.Min.X <= b.Max.X && b.Min.X <= a.Max.X && a.Min.Y <= b.Max.Y && b.Min.Y <= a.Max.Y
+}
+
+// area returns the number of pixels in the rectangles, which mustn't overlap.
+func area(rs []image.Rectangle) int {
+	n := 0
+	for _, r := range rs {
+		n += r.Dx() * r.Dy()
+	}
+	return n
+}
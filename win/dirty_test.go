@@ -0,0 +1,100 @@
+package win
+
+import (
+	"image"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestDirtyRectsAdd(t *testing.T) {
+	var many []image.Rectangle
+	for i := 0; i <= maxDirtyRects; i++ {
+		many = append(many, image.Rect(2*i, 0, 2*i+1, 1))
+	}
+
+	tests := []struct {
+		name string
+		add  []image.Rectangle
+		want []image.Rectangle
+	}{
+		{
+			name: "nothing",
+			add:  nil,
+			want: nil,
+		},
+		{
+			name: "empty",
+			add:  []image.Rectangle{{}, image.Rect(5, 5, 5, 10)},
+			want: nil,
+		},
+		{
+			name: "empty to a set",
+			add:  []image.Rectangle{image.Rect(0, 0, 10, 10), {}},
+			want: []image.Rectangle{image.Rect(0, 0, 10, 10)},
+		},
+		{
+			name: "disjoint",
+			add:  []image.Rectangle{image.Rect(0, 0, 10, 10), image.Rect(90, 90, 100, 100)},
+			want: []image.Rectangle{image.Rect(0, 0, 10, 10), image.Rect(90, 90, 100, 100)},
+		},
+		{
+			name: "overlapping",
+			add:  []image.Rectangle{image.Rect(0, 0, 10, 10), image.Rect(5, 5, 15, 15)},
+			want: []image.Rectangle{image.Rect(0, 0, 15, 15)},
+		},
+		{
+			name: "touching",
+			add:  []image.Rectangle{image.Rect(0, 0, 10, 10), image.Rect(10, 0, 20, 10)},
+			want: []image.Rectangle{image.Rect(0, 0, 20, 10)},
+		},
+		{
+			name: "one pixel apart",
+			add:  []image.Rectangle{image.Rect(0, 0, 10, 10), image.Rect(11, 0, 20, 10)},
+			want: []image.Rectangle{image.Rect(0, 0, 10, 10), image.Rect(11, 0, 20, 10)},
+		},
+		{
+			// the last one only touches the second, but merged with it, it touches the
+			// first, which was checked before
+			name: "chain",
+			add: []image.Rectangle{
+				image.Rect(0, 0, 6, 10),
+				image.Rect(5, 20, 30, 30),
+				image.Rect(20, 10, 25, 20),
+			},
+			want: []image.Rectangle{image.Rect(0, 0, 30, 30)},
+		},
+		{
+			name: "at the limit",
+			add:  many[:maxDirtyRects],
+			want: many[:maxDirtyRects],
+		},
+		{
+			name: "past the limit",
+			add:  many,
+			want: []image.Rectangle{image.Rect(0, 0, 2*maxDirtyRects+1, 1)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var d dirtyRects
+			for _, r := range tt.add {
+				d.add(r)
+			}
+			got := sorted(d)
+			if want := sorted(tt.want); !reflect.DeepEqual(got, want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+// sorted returns a sorted copy of rs, as the order of dirtyRects doesn't matter.
+func sorted(rs []image.Rectangle) []image.Rectangle {
+	s := append([]image.Rectangle(nil), rs...)
+	sort.Slice(s, func(i, j int) bool {
+		a, b := s[i].Min, s[j].Min
+		return a.Y < b.Y || a.Y == b.Y && a.X < b.X
+	})
+	return s
+}
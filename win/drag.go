@@ -0,0 +1,133 @@
+package win
+
+import (
+	"image"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+// Edge is a set of edges of the window, a corner is two of them.
+type Edge int
+
+// List of all edges.
+const (
+	EdgeLeft Edge = 1 << iota
+	EdgeRight
+	EdgeTop
+	EdgeBottom
+)
+
+// EdgeAt returns the edges of the drawing area bounds within border pixels of the point p,
+// or 0 if p is further inside or outside of bounds. It's the hit test for resize grips of a
+// borderless window: on a MoDown, start resizing with StartResize if it returns edges, and
+// show the cursor of SetStandardCursor that fits while the mouse hovers them.
+func EdgeAt(bounds image.Rectangle, p image.Point, border int) Edge {
+	if !p.In(bounds) {
+		return 0
+	}
+	var e Edge
+	if p.X < bounds.Min.X+border {
+		e |= EdgeLeft
+	} else if p.X >= bounds.Max.X-border {
+		e |= EdgeRight
+	}
+	if p.Y < bounds.Min.Y+border {
+		e |= EdgeTop
+	} else if p.Y >= bounds.Max.Y-border {
+		e |= EdgeBottom
+	}
+	return e
+}
+
+// windowDrag is a move or resize of the window by the mouse in progress.
+type windowDrag struct {
+	edges  Edge        // 0 for moving
+	cursor image.Point // where it started, in screen coordinates
+	pos    image.Point
+	size   image.Point
+}
+
+// StartDrag makes the window follow the mouse until the next mouse button gets released,
+// like dragging it by its title bar. Call it on a MoDown on the part of a borderless window
+// that serves as its title bar.
+//
+// It does nothing if the window is closed, or if no mouse button is down anymore, e.g.
+// because the button already got released after a quick click.
+func (w *Win) StartDrag() {
+	w.startDrag(0)
+}
+
+// StartResize makes the edges of the window follow the mouse until the next mouse button
+// gets released, like dragging its frame. Call it on a MoDown on a resize grip of a
+// borderless window, see EdgeAt. It works regardless of the Resizable option.
+//
+// It does nothing if the window is closed, edges is 0 or no mouse button is down anymore.
+func (w *Win) StartResize(edges Edge) {
+	if edges == 0 {
+		return
+	}
+	w.startDrag(edges)
+}
+
+func (w *Win) startDrag(edges Edge) {
+	w.callMain(func() {
+		if !w.buttonDown() {
+			// the release came before, nothing would end the drag
+			return
+		}
+		d := &windowDrag{edges: edges}
+		d.pos.X, d.pos.Y = w.w.GetPos()
+		d.size.X, d.size.Y = w.w.GetSize()
+		d.cursor = w.screenCursor()
+		w.drag = d
+	})
+}
+
+// buttonDown tells whether any mouse button is held down. It must be called on the main
+// thread.
+func (w *Win) buttonDown() bool {
+	for b := glfw.MouseButton1; b <= glfw.MouseButtonLast; b++ {
+		if w.w.GetMouseButton(b) == glfw.Press {
+			return true
+		}
+	}
+	return false
+}
+
+// screenCursor returns the position of the cursor in screen coordinates. It must be called
+// on the main thread.
+func (w *Win) screenCursor() image.Point {
+	wx, wy := w.w.GetPos()
+	x, y := w.w.GetCursorPos()
+	return image.Pt(wx+int(x), wy+int(y))
+}
+
+// updateDrag moves or resizes the window to where the mouse is now. It must be called on the
+// main thread.
+func (w *Win) updateDrag() {
+	d := w.drag
+	delta := w.screenCursor().Sub(d.cursor)
+	if d.edges == 0 {
+		w.w.SetPos(d.pos.X+delta.X, d.pos.Y+delta.Y)
+		return
+	}
+
+	// the opposite edges stay where they are
+	r := image.Rectangle{Min: d.pos, Max: d.pos.Add(d.size)}
+	if d.edges&EdgeLeft != 0 {
+		r.Min.X = min(r.Min.X+delta.X, r.Max.X-1)
+	}
+	if d.edges&EdgeRight != 0 {
+		r.Max.X = max(r.Max.X+delta.X, r.Min.X+1)
+	}
+	if d.edges&EdgeTop != 0 {
+		r.Min.Y = min(r.Min.Y+delta.Y, r.Max.Y-1)
+	}
+	if d.edges&EdgeBottom != 0 {
+		r.Max.Y = max(r.Max.Y+delta.Y, r.Min.Y+1)
+	}
+	if r.Min != d.pos {
+		w.w.SetPos(r.Min.X, r.Min.Y)
+	}
+	w.w.SetSize(r.Dx(), r.Dy())
+}
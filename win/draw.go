@@ -0,0 +1,230 @@
+package win
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+)
+
+// FillRect fills the rectangle r of dst with the color c. It returns the part of dst that
+// got changed, so it can be returned straight from a drawing function.
+func FillRect(dst draw.Image, r image.Rectangle, c color.Color) image.Rectangle {
+	r = r.Intersect(dst.Bounds())
+	draw.Draw(dst, r, image.NewUniform(c), image.Point{}, draw.Src)
+	return r
+}
+
+// DrawRect draws the outline of the rectangle r onto dst with the color c. The outline is
+// thickness pixels wide and lies inside r. It returns the part of dst that got changed.
+func DrawRect(dst draw.Image, r image.Rectangle, c color.Color, thickness int) image.Rectangle {
+	r = r.Canon()
+	if thickness <= 0 || r.Empty() {
+		return image.Rectangle{}
+	}
+	if 2*thickness >= r.Dx() || 2*thickness >= r.Dy() {
+		return FillRect(dst, r, c)
+	}
+	FillRect(dst, image.Rect(r.Min.X, r.Min.Y, r.Max.X, r.Min.Y+thickness), c)
+	FillRect(dst, image.Rect(r.Min.X, r.Max.Y-thickness, r.Max.X, r.Max.Y), c)
+	FillRect(dst, image.Rect(r.Min.X, r.Min.Y+thickness, r.Min.X+thickness, r.Max.Y-thickness), c)
+	FillRect(dst, image.Rect(r.Max.X-thickness, r.Min.Y+thickness, r.Max.X, r.Max.Y-thickness), c)
+	return r.Intersect(dst.Bounds())
+}
+
+// DrawLine draws a one pixel wide line from p0 to p1, both included, onto dst with the color
+// c, using Bresenham's algorithm. It returns the part of dst that got changed.
+func DrawLine(dst draw.Image, p0, p1 image.Point, c color.Color) image.Rectangle {
+	bounds := dst.Bounds()
+	dx, dy := abs(p1.X-p0.X), -abs(p1.Y-p0.Y)
+	sx, sy := 1, 1
+	if p0.X > p1.X {
+		sx = -1
+	}
+	if p0.Y > p1.Y {
+		sy = -1
+	}
+	e := dx + dy
+	for p := p0; ; {
+		if p.In(bounds) {
+			dst.Set(p.X, p.Y, c)
+		}
+		if p == p1 {
+			break
+		}
+		if 2*e >= dy {
+			e += dy
+			p.X += sx
+		}
+		if 2*e <= dx {
+			e += dx
+			p.Y += sy
+		}
+	}
+	r := image.Rectangle{Min: p0, Max: p1}.Canon()
+	r.Max = r.Max.Add(image.Pt(1, 1))
+	return r.Intersect(bounds)
+}
+
+// DrawLineAA draws an anti-aliased line from p0 to p1 onto dst with the color c, blending it
+// over what's already there. The line is thickness pixels wide with round ends and goes
+// through the centers of the pixels p0 and p1. It returns the part of dst that got changed.
+func DrawLineAA(dst draw.Image, p0, p1 image.Point, c color.Color, thickness float64) image.Rectangle {
+	if thickness <= 0 {
+		return image.Rectangle{}
+	}
+	a, b := pixelCenter(p0), pixelCenter(p1)
+	half := thickness / 2
+	d := [2]float64{b[0] - a[0], b[1] - a[1]}
+	length2 := d[0]*d[0] + d[1]*d[1]
+	r := image.Rectangle{Min: p0, Max: p1}.Canon()
+	return drawCoverage(dst, r.Inset(-int(math.Ceil(half))-1), c, func(x, y float64) float64 {
+		// distance to the closest point of the segment
+		t := 0.0
+		if length2 > 0 {
+			t = math.Max(0, math.Min(1, ((x-a[0])*d[0]+(y-a[1])*d[1])/length2))
+		}
+		dist := math.Hypot(x-a[0]-t*d[0], y-a[1]-t*d[1])
+		return half + 0.5 - dist
+	})
+}
+
+// DrawCircle draws the anti-aliased outline of a circle with the given radius around the
+// center of the pixel center onto dst with the color c, blending it over what's already
+// there. The outline is thickness pixels wide and centered on the radius. It returns the
+// part of dst that got changed.
+func DrawCircle(dst draw.Image, center image.Point, radius float64, c color.Color, thickness float64) image.Rectangle {
+	if thickness <= 0 || radius <= 0 {
+		return image.Rectangle{}
+	}
+	m, half := pixelCenter(center), thickness/2
+	r := image.Rectangle{Min: center, Max: center}
+	return drawCoverage(dst, r.Inset(-int(math.Ceil(radius+half))-1), c, func(x, y float64) float64 {
+		return half + 0.5 - math.Abs(math.Hypot(x-m[0], y-m[1])-radius)
+	})
+}
+
+// FillCircle is like DrawCircle, but fills the whole circle.
+func FillCircle(dst draw.Image, center image.Point, radius float64, c color.Color) image.Rectangle {
+	if radius <= 0 {
+		return image.Rectangle{}
+	}
+	m := pixelCenter(center)
+	r := image.Rectangle{Min: center, Max: center}
+	return drawCoverage(dst, r.Inset(-int(math.Ceil(radius))-1), c, func(x, y float64) float64 {
+		return radius + 0.5 - math.Hypot(x-m[0], y-m[1])
+	})
+}
+
+// pixelCenter returns the center of the pixel p.
+func pixelCenter(p image.Point) [2]float64 {
+	return [2]float64{float64(p.X) + 0.5, float64(p.Y) + 0.5}
+}
+
+// drawCoverage blends c over the pixels of dst within r, each one by the share of it that
+// the shape covers, as coverage returns it for the center of the pixel, clamped to [0, 1].
+// Blending goes through image/draw, so it composites premultiplied alpha correctly. It
+// returns the part of dst that got changed.
+func drawCoverage(dst draw.Image, r image.Rectangle, c color.Color, coverage func(x, y float64) float64) image.Rectangle {
+	r = r.Intersect(dst.Bounds())
+	if r.Empty() {
+		return image.Rectangle{}
+	}
+	mask := image.NewAlpha(r)
+	changed := image.Rectangle{}
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			cov := coverage(float64(x)+0.5, float64(y)+0.5)
+			if cov <= 0 {
+				continue
+			}
+			mask.SetAlpha(x, y, color.Alpha{uint8(math.Min(cov, 1)*0xff + 0.5)})
+			changed = changed.Union(image.Rect(x, y, x+1, y+1))
+		}
+	}
+	draw.DrawMask(dst, changed, image.NewUniform(c), image.Point{}, mask, changed.Min, draw.Over)
+	return changed
+}
+
+// DrawImage draws src onto dst with its top-left corner at the point at, blending it over
+// what's already there. It returns the part of dst that got changed.
+func DrawImage(dst draw.Image, src image.Image, at image.Point) image.Rectangle {
+	r := image.Rectangle{Min: at, Max: at.Add(src.Bounds().Size())}.Intersect(dst.Bounds())
+	draw.Draw(dst, r, src, src.Bounds().Min.Add(r.Min.Sub(at)), draw.Over)
+	return r
+}
+
+// DrawImageScaled is like DrawImage, but it scales src by the factor scale first, using
+// nearest neighbor sampling, which keeps pixel art crisp.
+func DrawImageScaled(dst draw.Image, src image.Image, at image.Point, scale float64) image.Rectangle {
+	if scale <= 0 {
+		return image.Rectangle{}
+	}
+	sb := src.Bounds()
+	size := image.Pt(int(float64(sb.Dx())*scale), int(float64(sb.Dy())*scale))
+	r := image.Rectangle{Min: at, Max: at.Add(size)}.Intersect(dst.Bounds())
+	if r.Empty() {
+		return image.Rectangle{}
+	}
+	scaled := image.NewRGBA(r)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		sy := sb.Min.Y + int(float64(y-at.Y)/scale)
+		for x := r.Min.X; x < r.Max.X; x++ {
+			sx := sb.Min.X + int(float64(x-at.X)/scale)
+			scaled.Set(x, y, src.At(sx, sy))
+		}
+	}
+	draw.Draw(dst, r, scaled, r.Min, draw.Over)
+	return r
+}
+
+// PremultiplyAlpha converts the pixels of img from straight alpha, where the color doesn't
+// depend on the alpha, to premultiplied alpha, where the color is multiplied by the alpha,
+// in place.
+//
+// The gui expects premultiplied alpha, that's what an *image.RGBA holds by definition and
+// what the image/draw package produces from any color.Color. It's only needed for pixels
+// written straight into the Pix slice from a straight alpha source, like raw RGBA data of
+// a file or of a library.
+func PremultiplyAlpha(img *image.RGBA) {
+	forEachPixel(img, func(p []uint8) {
+		a := uint32(p[3])
+		p[0] = uint8(uint32(p[0]) * a / 0xff)
+		p[1] = uint8(uint32(p[1]) * a / 0xff)
+		p[2] = uint8(uint32(p[2]) * a / 0xff)
+	})
+}
+
+// UnpremultiplyAlpha converts the pixels of img from premultiplied alpha back to straight
+// alpha, in place, e.g. to hand them to something that expects straight alpha. Fully
+// transparent pixels end up black. See PremultiplyAlpha.
+func UnpremultiplyAlpha(img *image.RGBA) {
+	forEachPixel(img, func(p []uint8) {
+		a := uint32(p[3])
+		if a == 0 {
+			p[0], p[1], p[2] = 0, 0, 0
+			return
+		}
+		p[0] = uint8(min(uint32(p[0])*0xff/a, 0xff))
+		p[1] = uint8(min(uint32(p[1])*0xff/a, 0xff))
+		p[2] = uint8(min(uint32(p[2])*0xff/a, 0xff))
+	})
+}
+
+// forEachPixel calls f with the 4 bytes of each pixel of img.
+func forEachPixel(img *image.RGBA, f func(p []uint8)) {
+	r := img.Bounds()
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		row := img.Pix[img.PixOffset(r.Min.X, y):img.PixOffset(r.Max.X, y)]
+		for i := 0; i < len(row); i += 4 {
+			f(row[i : i+4 : i+4])
+		}
+	}
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,77 @@
+package win
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestDrawLineAA(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 20, 20))
+	r := DrawLineAA(img, image.Pt(2, 10), image.Pt(17, 10), color.White, 1)
+
+	if a := img.RGBAAt(10, 10).A; a != 0xff {
+		t.Errorf("alpha on the line = %d, want fully covered", a)
+	}
+	if a := img.RGBAAt(10, 12).A; a != 0 {
+		t.Errorf("alpha 2 pixels off the line = %d, want untouched", a)
+	}
+	// a thicker line reaches half into the neighboring rows
+	r2 := DrawLineAA(img, image.Pt(2, 5), image.Pt(17, 5), color.White, 2)
+	if a := img.RGBAAt(10, 4).A; a < 0x60 || a > 0xa0 {
+		t.Errorf("alpha at the edge of a 2 pixel line = %d, want about half", a)
+	}
+	assertChangedWithin(t, img, r.Union(r2))
+
+	if r := DrawLineAA(img, image.Pt(2, 2), image.Pt(5, 5), color.White, 0); !r.Empty() {
+		t.Errorf("line of thickness 0 changed %v", r)
+	}
+}
+
+func TestFillCircle(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 20, 20))
+	r := FillCircle(img, image.Pt(10, 10), 5, color.RGBA{0, 0, 0x80, 0x80})
+
+	if c := img.RGBAAt(10, 10); c != (color.RGBA{0, 0, 0x80, 0x80}) {
+		t.Errorf("center = %v, want the color", c)
+	}
+	// the pixel centered on the circle line is covered half
+	if a := img.RGBAAt(15, 10).A; a < 0x30 || a > 0x50 {
+		t.Errorf("alpha on the edge = %d, want about half of 0x80", a)
+	}
+	if a := img.RGBAAt(17, 10).A; a != 0 {
+		t.Errorf("alpha outside = %d, want untouched", a)
+	}
+	for _, c := range []color.RGBA{img.RGBAAt(15, 10), img.RGBAAt(13, 13)} {
+		if c.B > c.A {
+			t.Errorf("pixel %v isn't premultiplied", c)
+		}
+	}
+	assertChangedWithin(t, img, r)
+}
+
+func TestDrawCircle(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 20, 20))
+	r := DrawCircle(img, image.Pt(10, 10), 6, color.White, 1)
+
+	if a := img.RGBAAt(16, 10).A; a != 0xff {
+		t.Errorf("alpha on the outline = %d, want fully covered", a)
+	}
+	if a := img.RGBAAt(10, 10).A; a != 0 {
+		t.Errorf("alpha in the center = %d, want untouched", a)
+	}
+	assertChangedWithin(t, img, r)
+}
+
+// assertChangedWithin checks that the pixels of img outside r are all untouched.
+func assertChangedWithin(t *testing.T, img *image.RGBA, r image.Rectangle) {
+	t.Helper()
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if c := img.RGBAAt(x, y); c.A != 0 && !image.Pt(x, y).In(r) {
+				t.Fatalf("pixel %v changed to %v outside of the returned %v", image.Pt(x, y), c, r)
+			}
+		}
+	}
+}
@@ -3,6 +3,9 @@ package win
 import (
 	"fmt"
 	"image"
+	"time"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
 )
 
 // Button indicates a mouse button in an event.
@@ -37,53 +40,253 @@ const (
 	KeyShift
 	KeyCtrl
 	KeyAlt
+
+	// KeyUnknown is any other key. Tell those apart by the scancode.
+	KeyUnknown
+)
+
+var keyNames = [...]string{
+	KeyLeft:      "left",
+	KeyRight:     "right",
+	KeyUp:        "up",
+	KeyDown:      "down",
+	KeyEscape:    "escape",
+	KeySpace:     "space",
+	KeyBackspace: "backspace",
+	KeyDelete:    "delete",
+	KeyEnter:     "enter",
+	KeyTab:       "tab",
+	KeyHome:      "home",
+	KeyEnd:       "end",
+	KeyPageUp:    "pageup",
+	KeyPageDown:  "pagedown",
+	KeyShift:     "shift",
+	KeyCtrl:      "ctrl",
+	KeyAlt:       "alt",
+	KeyUnknown:   "unknown",
+}
+
+// String returns the name of the key, e.g. "pageup".
+func (k Key) String() string {
+	if k < 0 || int(k) >= len(keyNames) {
+		return fmt.Sprintf("key(%d)", int(k))
+	}
+	return keyNames[k]
+}
+
+// CloseReason tells why a WiClose event happened.
+type CloseReason int
+
+// List of all close reasons.
+const (
+	// CloseReasonUser is the user pressing the close button of the window, or the shortcut
+	// of the OS for it, like Alt+F4.
+	CloseReasonUser CloseReason = iota
+
+	// CloseReasonProgrammatic is the app calling RequestClose.
+	CloseReasonProgrammatic
+
+	// CloseReasonContext is the context of a window created with NewWithContext being done.
+	CloseReasonContext
+)
+
+// Modifier is a set of modifier keys held down during an event.
+type Modifier int
+
+// List of all modifier keys.
+const (
+	ModShift Modifier = 1 << iota
+	ModCtrl
+	ModAlt
+	ModSuper
 )
 
+// Stamp tells when an input event happened, the moment the window got it from the OS. It's
+// embedded in all the mouse and keyboard events, e.g. for the velocity of a swipe or for
+// measuring input latency.
+type Stamp struct{ Time time.Time }
+
+// Timestamp returns the Time field, so all events with a Stamp can be handled together.
+func (s Stamp) Timestamp() time.Time { return s.Time }
+
 type (
-	// WiClose is an event that happens when the user presses the close button on the window.
-	WiClose struct{}
+	// WiClose is an event that happens when the user presses the close button on the window,
+	// or something else asks to close it. The Reason field tells which, e.g. to only ask about
+	// unsaved changes when the user closes the window.
+	//
+	// The window stays open, close it with ConfirmClose or by closing the Draw() channel. Only
+	// with CloseReasonContext, the window closes right after the event anyway.
+	WiClose struct{ Reason CloseReason }
+
+	// WiMove is an event that happens when the window gets moved.
+	//
+	// The Point field tells the new position of the top-left corner of the window on the screen.
+	WiMove struct{ image.Point }
+
+	// WiMinimize is an event that happens when the window gets minimized (iconified).
+	WiMinimize struct{}
+
+	// WiRestore is an event that happens when the window gets restored from being minimized
+	// or maximized.
+	WiRestore struct{}
+
+	// WiMaximize is an event that happens when the window gets maximized.
+	WiMaximize struct{}
+
+	// WiRefresh is an event that happens when the OS asks the window to repaint itself, e.g.
+	// after it got uncovered. The window repaints the gui by itself, the event is for apps
+	// that need to render their Open GL content again.
+	WiRefresh struct{}
 
 	// MoMove is an event that happens when the mouse gets moved across the window.
-	MoMove struct{ image.Point }
+	MoMove struct {
+		image.Point
+		Stamp
+	}
+
+	// MoMoveF is an event that happens together with MoMove, right after it, with the exact
+	// position of the mouse in fractions of pixels. It's for smooth drawing and precise
+	// dragging.
+	MoMoveF struct {
+		X, Y float64
+		Stamp
+	}
+
+	// MoMoveRel is an event that happens together with MoMove, right after it, while the
+	// cursor is captured with SetCursorCapture. It tells how far the mouse moved, in pixels
+	// of the drawing area, which keeps working when the cursor would leave the window.
+	MoMoveRel struct {
+		Dx, Dy float64
+		Stamp
+	}
 
 	// MoDown is an event that happens when a mouse button gets pressed.
+	//
+	// The Pressure field tells how hard a pen got pressed on a tablet, from 0 to 1. It's 1
+	// for a mouse and wherever the pressure isn't known, which is currently everywhere, as
+	// GLFW doesn't report it.
 	MoDown struct {
 		image.Point
-		Button Button
+		Button   Button
+		Pressure float32
+		Stamp
 	}
 
 	// MoUp is an event that happens when a mouse button gets released.
 	MoUp struct {
 		image.Point
 		Button Button
+		Stamp
 	}
 
 	// MoScroll is an event that happens on scrolling the mouse.
 	//
-	// The Point field tells the amount scrolled in each direction.
-	MoScroll struct{ image.Point }
+	// The Point field tells the amount scrolled in each direction, X horizontally and Y
+	// vertically, see the Horizontal and Vertical methods. Trackpads and tilting mouse wheels
+	// scroll horizontally too. The Cursor field tells where the mouse was when scrolling.
+	MoScroll struct {
+		image.Point
+		Cursor image.Point
+		Stamp
+	}
+
+	// MoScrollF is an event that happens together with MoScroll, right after it, with the
+	// exact amount scrolled. Trackpads scroll by fractions, which MoScroll rounds to zero.
+	MoScrollF struct {
+		X, Y float64
+		Stamp
+	}
 
 	// KbType is an event that happens when a Unicode character gets typed on the keyboard.
-	KbType struct{ Rune rune }
+	//
+	// The Mod field tells which modifier keys were held down while typing.
+	//
+	// Text composed with an input method, e.g. for CJK languages, arrives as one KbType per
+	// rune once it's committed. The composition in progress isn't reported, GLFW 3.3 has no
+	// callbacks for it.
+	KbType struct {
+		Rune rune
+		Mod  Modifier
+		Stamp
+	}
 
 	// KbDown is an event that happens when a key on the keyboard gets pressed.
-	KbDown struct{ Key Key }
+	//
+	// The Scancode field identifies the physical key, regardless of the keyboard layout. It's
+	// specific to the platform, but stays the same between runs. Use KeyName to get a label
+	// for it.
+	//
+	// The Mod field tells which modifier keys were held down when the key got pressed, see
+	// also Shortcut.
+	KbDown struct {
+		Key      Key
+		Scancode int
+		Mod      Modifier
+		Stamp
+	}
 
 	// KbUp is an event that happens when a key on the keyboard gets released.
-	KbUp struct{ Key Key }
+	KbUp struct {
+		Key      Key
+		Scancode int
+		Mod      Modifier
+		Stamp
+	}
 
 	// KbRepeat is an event that happens when a key on the keyboard gets repeated.
 	//
-	// This happens when its held down for some time.
-	KbRepeat struct{ Key Key }
+	// This happens when its held down for some time. The Held field tells for how long, since
+	// the KbDown event of the key.
+	KbRepeat struct {
+		Key      Key
+		Scancode int
+		Mod      Modifier
+		Held     time.Duration
+		Stamp
+	}
+
+	// Tick is an event that happens periodically after calling Ticker. The T field tells the
+	// time of the tick.
+	Tick struct{ T time.Time }
 )
 
-func (wc WiClose) String() string  { return "wi/close" }
-func (mm MoMove) String() string   { return fmt.Sprintf("mo/move/%d/%d", mm.X, mm.Y) }
-func (md MoDown) String() string   { return fmt.Sprintf("mo/down/%d/%d/%s", md.X, md.Y, md.Button) }
-func (mu MoUp) String() string     { return fmt.Sprintf("mo/up/%d/%d/%s", mu.X, mu.Y, mu.Button) }
-func (ms MoScroll) String() string { return fmt.Sprintf("mo/scroll/%d/%d", ms.X, ms.Y) }
-func (kt KbType) String() string   { return fmt.Sprintf("kb/type/%d", kt.Rune) }
-func (kd KbDown) String() string   { return fmt.Sprintf("kb/down/%s", kd.Key) }
-func (ku KbUp) String() string     { return fmt.Sprintf("kb/up/%s", ku.Key) }
-func (kr KbRepeat) String() string { return fmt.Sprintf("kb/repeat/%s", kr.Key) }
+func (wc WiClose) String() string    { return "wi/close" }
+func (wm WiMove) String() string     { return fmt.Sprintf("wi/move/%d/%d", wm.X, wm.Y) }
+func (wm WiMinimize) String() string { return "wi/minimize" }
+func (wr WiRestore) String() string  { return "wi/restore" }
+func (wm WiMaximize) String() string { return "wi/maximize" }
+func (wr WiRefresh) String() string  { return "wi/refresh" }
+func (mm MoMove) String() string     { return fmt.Sprintf("mo/move/%d/%d", mm.X, mm.Y) }
+func (mm MoMoveF) String() string    { return fmt.Sprintf("mo/movef/%g/%g", mm.X, mm.Y) }
+func (mm MoMoveRel) String() string  { return fmt.Sprintf("mo/moverel/%g/%g", mm.Dx, mm.Dy) }
+func (md MoDown) String() string     { return fmt.Sprintf("mo/down/%d/%d/%s", md.X, md.Y, md.Button) }
+func (mu MoUp) String() string       { return fmt.Sprintf("mo/up/%d/%d/%s", mu.X, mu.Y, mu.Button) }
+func (ms MoScroll) String() string {
+	return fmt.Sprintf("mo/scroll/%d/%d/%d/%d", ms.X, ms.Y, ms.Cursor.X, ms.Cursor.Y)
+}
+func (ms MoScrollF) String() string { return fmt.Sprintf("mo/scrollf/%g/%g", ms.X, ms.Y) }
+func (kt KbType) String() string    { return fmt.Sprintf("kb/type/%d", kt.Rune) }
+func (kd KbDown) String() string    { return fmt.Sprintf("kb/down/%s", kd.Key) }
+func (ku KbUp) String() string      { return fmt.Sprintf("kb/up/%s", ku.Key) }
+func (kr KbRepeat) String() string  { return fmt.Sprintf("kb/repeat/%s", kr.Key) }
+func (t Tick) String() string       { return fmt.Sprintf("tick/%d", t.T.UnixNano()) }
+
+// Horizontal returns the amount scrolled horizontally, the X of the Point field.
+func (ms MoScroll) Horizontal() int { return ms.X }
+
+// Vertical returns the amount scrolled vertically, the Y of the Point field. It's positive
+// when scrolling up, away from the user.
+func (ms MoScroll) Vertical() int { return ms.Y }
+
+// KeyName returns the label of the physical key with the given scancode in the current
+// keyboard layout, for example "w" on QWERTY and "z" on AZERTY for the same key. It returns
+// an empty string for keys without a printable label, like the arrow keys.
+//
+// KeyName must only be called while a window is open.
+func KeyName(scancode int) string {
+	var name string
+	callMain(func() {
+		name = glfw.GetKeyName(glfw.KeyUnknown, scancode)
+	})
+	return name
+}
@@ -0,0 +1,79 @@
+package win
+
+import "time"
+
+// frameWindow is the number of frames FrameStats averages over.
+const frameWindow = 60
+
+// FrameStats tells how fast the window puts frames on the screen.
+type FrameStats struct {
+	// Last is the time between the last two frames.
+	Last time.Duration
+
+	// Average is the average time between frames over the last 60 frames, or fewer if
+	// there weren't that many yet.
+	Average time.Duration
+
+	// Frames is the number of frames put on the screen so far.
+	Frames uint64
+}
+
+// FPS returns the average number of frames per second, or 0 if there were no frames yet.
+func (fs FrameStats) FPS() float64 {
+	if fs.Average <= 0 {
+		return 0
+	}
+	return float64(time.Second) / float64(fs.Average)
+}
+
+// frameTimer records the times between the frames. It's only used on the Open GL thread.
+type frameTimer struct {
+	last      time.Time
+	durations [frameWindow]time.Duration
+	frames    uint64
+}
+
+// frame records a frame put on the screen at time t.
+func (ft *frameTimer) frame(t time.Time) {
+	if ft.frames > 0 {
+		ft.durations[(ft.frames-1)%frameWindow] = t.Sub(ft.last)
+	}
+	ft.frames++
+	ft.last = t
+}
+
+func (ft *frameTimer) stats() FrameStats {
+	// the number of durations between the frames so far
+	n := ft.frames
+	if n > 0 {
+		n--
+	}
+	if n == 0 {
+		return FrameStats{Frames: ft.frames}
+	}
+	last := ft.durations[(n-1)%frameWindow]
+	if n > frameWindow {
+		n = frameWindow
+	}
+	var sum time.Duration
+	for _, d := range ft.durations[:n] {
+		sum += d
+	}
+	return FrameStats{
+		Last:    last,
+		Average: sum / time.Duration(n),
+		Frames:  ft.frames,
+	}
+}
+
+// FrameStats returns the timing of the frames the window put on the screen so far. The
+// window only puts a frame on the screen when something changed, so this measures the time
+// between updates, not a fixed refresh rate. The zero FrameStats gets returned after the
+// window got closed.
+func (w *Win) FrameStats() FrameStats {
+	reply := make(chan FrameStats, 1)
+	if !w.glCall(func() { reply <- w.frames.stats() }) {
+		return FrameStats{}
+	}
+	return <-reply
+}
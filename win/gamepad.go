@@ -0,0 +1,180 @@
+package win
+
+import (
+	"fmt"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+// GamepadButton indicates a gamepad button in an event, named after the Xbox controller.
+type GamepadButton string
+
+// List of all gamepad buttons.
+const (
+	GamepadA           GamepadButton = "a"
+	GamepadB           GamepadButton = "b"
+	GamepadX           GamepadButton = "x"
+	GamepadY           GamepadButton = "y"
+	GamepadLeftBumper  GamepadButton = "left-bumper"
+	GamepadRightBumper GamepadButton = "right-bumper"
+	GamepadBack        GamepadButton = "back"
+	GamepadStart       GamepadButton = "start"
+	GamepadGuide       GamepadButton = "guide"
+	GamepadLeftThumb   GamepadButton = "left-thumb"
+	GamepadRightThumb  GamepadButton = "right-thumb"
+	GamepadDpadUp      GamepadButton = "dpad-up"
+	GamepadDpadRight   GamepadButton = "dpad-right"
+	GamepadDpadDown    GamepadButton = "dpad-down"
+	GamepadDpadLeft    GamepadButton = "dpad-left"
+)
+
+// GamepadAxis indicates a gamepad axis in an event.
+type GamepadAxis string
+
+// List of all gamepad axes.
+const (
+	GamepadLeftX        GamepadAxis = "left-x"
+	GamepadLeftY        GamepadAxis = "left-y"
+	GamepadRightX       GamepadAxis = "right-x"
+	GamepadRightY       GamepadAxis = "right-y"
+	GamepadLeftTrigger  GamepadAxis = "left-trigger"
+	GamepadRightTrigger GamepadAxis = "right-trigger"
+)
+
+var gamepadButtons = map[glfw.GamepadButton]GamepadButton{
+	glfw.ButtonA:           GamepadA,
+	glfw.ButtonB:           GamepadB,
+	glfw.ButtonX:           GamepadX,
+	glfw.ButtonY:           GamepadY,
+	glfw.ButtonLeftBumper:  GamepadLeftBumper,
+	glfw.ButtonRightBumper: GamepadRightBumper,
+	glfw.ButtonBack:        GamepadBack,
+	glfw.ButtonStart:       GamepadStart,
+	glfw.ButtonGuide:       GamepadGuide,
+	glfw.ButtonLeftThumb:   GamepadLeftThumb,
+	glfw.ButtonRightThumb:  GamepadRightThumb,
+	glfw.ButtonDpadUp:      GamepadDpadUp,
+	glfw.ButtonDpadRight:   GamepadDpadRight,
+	glfw.ButtonDpadDown:    GamepadDpadDown,
+	glfw.ButtonDpadLeft:    GamepadDpadLeft,
+}
+
+var gamepadAxes = map[glfw.GamepadAxis]GamepadAxis{
+	glfw.AxisLeftX:        GamepadLeftX,
+	glfw.AxisLeftY:        GamepadLeftY,
+	glfw.AxisRightX:       GamepadRightX,
+	glfw.AxisRightY:       GamepadRightY,
+	glfw.AxisLeftTrigger:  GamepadLeftTrigger,
+	glfw.AxisRightTrigger: GamepadRightTrigger,
+}
+
+// GamepadState is the state of a gamepad at one moment.
+//
+// Sticks range from -1 to 1, positive being right and down. Triggers range from -1 when
+// released to 1 when fully pressed.
+type GamepadState struct {
+	ID      int
+	Name    string
+	Buttons map[GamepadButton]bool
+	Axes    map[GamepadAxis]float64
+}
+
+type (
+	// JoyConnect is an event that happens when a joystick gets connected. The ID field
+	// identifies the joystick in the other joystick events.
+	JoyConnect struct{ ID int }
+
+	// JoyDisconnect is an event that happens when a joystick gets disconnected.
+	JoyDisconnect struct{ ID int }
+
+	// JoyButton is an event that happens when a button on a gamepad gets pressed or released.
+	JoyButton struct {
+		ID      int
+		Button  GamepadButton
+		Pressed bool
+	}
+
+	// JoyAxis is an event that happens when an axis of a gamepad changes its value.
+	JoyAxis struct {
+		ID    int
+		Axis  GamepadAxis
+		Value float64
+	}
+)
+
+func (jc JoyConnect) String() string    { return fmt.Sprintf("joy/connect/%d", jc.ID) }
+func (jd JoyDisconnect) String() string { return fmt.Sprintf("joy/disconnect/%d", jd.ID) }
+func (jb JoyButton) String() string {
+	if jb.Pressed {
+		return fmt.Sprintf("joy/button/%d/%s/down", jb.ID, jb.Button)
+	}
+	return fmt.Sprintf("joy/button/%d/%s/up", jb.ID, jb.Button)
+}
+func (ja JoyAxis) String() string { return fmt.Sprintf("joy/axis/%d/%s/%f", ja.ID, ja.Axis, ja.Value) }
+
+// Gamepads returns the current state of all the connected gamepads, that is joysticks with
+// a known button and axis mapping.
+//
+// Gamepads must only be called while a window is open.
+func Gamepads() []GamepadState {
+	var states []GamepadState
+	callMain(func() {
+		for joy := glfw.Joystick1; joy <= glfw.JoystickLast; joy++ {
+			if state, ok := gamepadState(joy); ok {
+				states = append(states, state)
+			}
+		}
+	})
+	return states
+}
+
+// gamepadState polls the state of a joystick, if it's a gamepad. Only call on the main thread.
+func gamepadState(joy glfw.Joystick) (GamepadState, bool) {
+	if !joy.Present() || !joy.IsGamepad() {
+		return GamepadState{}, false
+	}
+	gs := joy.GetGamepadState()
+	if gs == nil {
+		return GamepadState{}, false
+	}
+	state := GamepadState{
+		ID:      int(joy),
+		Name:    joy.GetGamepadName(),
+		Buttons: make(map[GamepadButton]bool, len(gamepadButtons)),
+		Axes:    make(map[GamepadAxis]float64, len(gamepadAxes)),
+	}
+	for b, name := range gamepadButtons {
+		state.Buttons[name] = gs.Buttons[b] == glfw.Press
+	}
+	for a, name := range gamepadAxes {
+		state.Axes[name] = float64(gs.Axes[a])
+	}
+	return state, true
+}
+
+// pollGamepads produces joystick events for whatever changed on the gamepads since the last
+// poll. Only call on the main thread.
+func (w *Win) pollGamepads() {
+	for joy := glfw.Joystick1; joy <= glfw.JoystickLast; joy++ {
+		state, ok := gamepadState(joy)
+		if !ok {
+			delete(w.gamepads, joy)
+			continue
+		}
+		prev, ok := w.gamepads[joy]
+		w.gamepads[joy] = state
+		if !ok {
+			continue
+		}
+		for b, pressed := range state.Buttons {
+			if pressed != prev.Buttons[b] {
+				w.eventsIn <- JoyButton{state.ID, b, pressed}
+			}
+		}
+		for a, value := range state.Axes {
+			if value != prev.Axes[a] {
+				w.eventsIn <- JoyAxis{state.ID, a, value}
+			}
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package win
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/bbeni/guiGL"
+)
+
+// Direction is the direction of a swipe.
+type Direction int
+
+// List of all directions.
+const (
+	DirLeft Direction = iota
+	DirRight
+	DirUp
+	DirDown
+)
+
+func (d Direction) String() string {
+	switch d {
+	case DirLeft:
+		return "left"
+	case DirRight:
+		return "right"
+	case DirUp:
+		return "up"
+	case DirDown:
+		return "down"
+	}
+	return fmt.Sprintf("direction(%d)", int(d))
+}
+
+type (
+	// GesturePinch is an event that a GestureRecognizer produces for a pinch, which it
+	// recognizes from scrolling while Ctrl is held down, the way most trackpad drivers
+	// report pinching. The Scale field tells the factor to zoom by, larger than 1 for
+	// zooming in.
+	GesturePinch struct {
+		Scale float64
+		Stamp
+	}
+
+	// GestureSwipe is an event that a GestureRecognizer produces for a quick drag with a
+	// mouse button held down. The Dir field tells where it went.
+	GestureSwipe struct {
+		Dir Direction
+		Stamp
+	}
+)
+
+func (gp GesturePinch) String() string { return fmt.Sprintf("ge/pinch/%g", gp.Scale) }
+func (gs GestureSwipe) String() string { return fmt.Sprintf("ge/swipe/%s", gs.Dir) }
+
+// GestureRecognizer turns the mouse and keyboard events of a window into gesture events,
+// GesturePinch and GestureSwipe. Set the fields to change the thresholds before using it.
+type GestureRecognizer struct {
+	// PinchStep is how much one unit of scrolling zooms, the Scale of a GesturePinch is
+	// e raised to the amount scrolled times PinchStep.
+	PinchStep float64
+
+	// SwipeDistance is how far a drag needs to go for a swipe, in pixels of the drawing area.
+	SwipeDistance float64
+
+	// SwipeTime is how long a drag may take at most for a swipe.
+	SwipeTime time.Duration
+
+	ctrl bool
+	down *MoDown
+}
+
+// NewGestureRecognizer returns a GestureRecognizer with the default thresholds: a PinchStep
+// of 0.1, a SwipeDistance of 100 pixels and a SwipeTime of 300 milliseconds.
+func NewGestureRecognizer() *GestureRecognizer {
+	return &GestureRecognizer{
+		PinchStep:     0.1,
+		SwipeDistance: 100,
+		SwipeTime:     300 * time.Millisecond,
+	}
+}
+
+// Recognize takes the next event of the window and returns the events to handle in its
+// place. That's usually the event itself, followed by a gesture it completed, if any. The
+// scroll events of a pinch get replaced by the GesturePinch.
+//
+// Recognize needs to see all events in order, so call it from a single goroutine.
+func (g *GestureRecognizer) Recognize(e gui.Event) []gui.Event {
+	switch e := e.(type) {
+	case KbDown:
+		if e.Key == KeyCtrl {
+			g.ctrl = true
+		}
+	case KbUp:
+		if e.Key == KeyCtrl {
+			g.ctrl = false
+		}
+	case MoScroll:
+		if g.ctrl {
+			// the MoScrollF right after it makes the pinch
+			return nil
+		}
+	case MoScrollF:
+		if g.ctrl {
+			return []gui.Event{GesturePinch{math.Exp(e.Y * g.PinchStep), e.Stamp}}
+		}
+	case MoDown:
+		g.down = &e
+	case MoUp:
+		down := g.down
+		g.down = nil
+		if down == nil || down.Button != e.Button || e.Time.Sub(down.Time) > g.SwipeTime {
+			break
+		}
+		d := e.Point.Sub(down.Point)
+		if math.Hypot(float64(d.X), float64(d.Y)) < g.SwipeDistance {
+			break
+		}
+		dir := DirRight
+		switch {
+		case abs(d.X) >= abs(d.Y) && d.X < 0:
+			dir = DirLeft
+		case abs(d.X) < abs(d.Y) && d.Y < 0:
+			dir = DirUp
+		case abs(d.X) < abs(d.Y):
+			dir = DirDown
+		}
+		return []gui.Event{e, GestureSwipe{dir, e.Stamp}}
+	}
+	return []gui.Event{e}
+}
+
+// Filter runs the events through Recognize on a goroutine of its own and returns a channel
+// of the resulting events, e.g. Filter(w.Events()). The channel has an unlimited capacity,
+// like the one of the window, and gets closed after events got closed.
+func (g *GestureRecognizer) Filter(events <-chan gui.Event) <-chan gui.Event {
+	out, in := gui.MakeEventsChan()
+	go func() {
+		for e := range events {
+			for _, x := range g.Recognize(e) {
+				in <- x
+			}
+		}
+		close(in)
+	}()
+	return out
+}
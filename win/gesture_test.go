@@ -0,0 +1,68 @@
+package win
+
+import (
+	"image"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bbeni/guiGL"
+)
+
+func TestRecognizePinch(t *testing.T) {
+	g := NewGestureRecognizer()
+	ctrl := KbDown{Key: KeyCtrl, Mod: ModCtrl}
+	scroll := MoScroll{Point: image.Pt(0, 1)}
+	scrollF := MoScrollF{Y: 1}
+
+	if got := g.Recognize(scrollF); !reflect.DeepEqual(got, []gui.Event{scrollF}) {
+		t.Errorf("scrolling without Ctrl = %v, want the scroll", got)
+	}
+	g.Recognize(ctrl)
+	if got := g.Recognize(scroll); len(got) != 0 {
+		t.Errorf("scrolling with Ctrl = %v, want it dropped", got)
+	}
+	got := g.Recognize(scrollF)
+	if len(got) != 1 {
+		t.Fatalf("fine scrolling with Ctrl = %v, want a pinch", got)
+	}
+	pinch, ok := got[0].(GesturePinch)
+	if !ok || math.Abs(pinch.Scale-math.Exp(g.PinchStep)) > 1e-9 {
+		t.Errorf("fine scrolling with Ctrl = %v, want a pinch by %g", got, math.Exp(g.PinchStep))
+	}
+	g.Recognize(KbUp{Key: KeyCtrl})
+	if got := g.Recognize(scrollF); !reflect.DeepEqual(got, []gui.Event{scrollF}) {
+		t.Errorf("scrolling after releasing Ctrl = %v, want the scroll", got)
+	}
+}
+
+func TestRecognizeSwipe(t *testing.T) {
+	start := time.Now()
+	at := func(d time.Duration) Stamp { return Stamp{start.Add(d)} }
+	tests := []struct {
+		name  string
+		to    image.Point
+		after time.Duration
+		want  []gui.Event
+	}{
+		{"left", image.Pt(0, 200), 100 * time.Millisecond, []gui.Event{GestureSwipe{DirLeft, at(100 * time.Millisecond)}}},
+		{"right", image.Pt(400, 200), 100 * time.Millisecond, []gui.Event{GestureSwipe{DirRight, at(100 * time.Millisecond)}}},
+		{"up", image.Pt(220, 0), 100 * time.Millisecond, []gui.Event{GestureSwipe{DirUp, at(100 * time.Millisecond)}}},
+		{"down", image.Pt(180, 400), 100 * time.Millisecond, []gui.Event{GestureSwipe{DirDown, at(100 * time.Millisecond)}}},
+		{"too short", image.Pt(250, 200), 100 * time.Millisecond, nil},
+		{"too slow", image.Pt(0, 200), time.Second, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGestureRecognizer()
+			down := MoDown{Point: image.Pt(200, 200), Button: ButtonLeft, Stamp: at(0)}
+			up := MoUp{Point: tt.to, Button: ButtonLeft, Stamp: at(tt.after)}
+			g.Recognize(down)
+			want := append([]gui.Event{up}, tt.want...)
+			if got := g.Recognize(up); !reflect.DeepEqual(got, want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
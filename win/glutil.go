@@ -0,0 +1,178 @@
+package win
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"strings"
+	"unsafe"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+var glErrorNames = map[uint32]string{
+	gl.INVALID_ENUM:                  "GL_INVALID_ENUM",
+	gl.INVALID_VALUE:                 "GL_INVALID_VALUE",
+	gl.INVALID_OPERATION:             "GL_INVALID_OPERATION",
+	gl.STACK_OVERFLOW:                "GL_STACK_OVERFLOW",
+	gl.STACK_UNDERFLOW:               "GL_STACK_UNDERFLOW",
+	gl.OUT_OF_MEMORY:                 "GL_OUT_OF_MEMORY",
+	gl.INVALID_FRAMEBUFFER_OPERATION: "GL_INVALID_FRAMEBUFFER_OPERATION",
+}
+
+// CheckGLError returns an error listing all the Open GL errors recorded since the last
+// check, or nil if there are none. The label gets included in the error message to tell
+// which check it came from.
+//
+// It must be called on the Open GL thread, i.e. from a function sent to the GL() channel.
+func CheckGLError(label string) error {
+	var codes []string
+	// without a current context GetError may never run out of errors, so bound the loop
+	for i := 0; i < 16; i++ {
+		code := gl.GetError()
+		if code == gl.NO_ERROR {
+			break
+		}
+		name, ok := glErrorNames[code]
+		if !ok {
+			name = fmt.Sprintf("0x%04x", code)
+		}
+		codes = append(codes, name)
+	}
+	if len(codes) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%s: open gl error: %s", label, strings.Join(codes, ", "))
+}
+
+// EnableGLDebugOutput makes the Open GL driver report its debug messages, which then get
+// logged, see SetLogger. Messages of high severity get the LogError level, notifications
+// LogDebug and all others LogWarning.
+//
+// It must be called on the Open GL thread, i.e. from a function sent to the GL() channel.
+// Debug output is part of Open GL 4.3, on older versions it requires the KHR_debug
+// extension, EnableGLDebugOutput returns an error if the context has neither. Most drivers
+// only report messages in a debug context.
+func EnableGLDebugOutput() error {
+	if !hasDebugOutput() {
+		return errors.New("open gl debug output not supported, needs open gl 4.3 or KHR_debug")
+	}
+	gl.Enable(gl.DEBUG_OUTPUT)
+	gl.Enable(gl.DEBUG_OUTPUT_SYNCHRONOUS)
+	gl.DebugMessageCallback(func(source, gltype, id, severity uint32, length int32, message string, userParam unsafe.Pointer) {
+		level := LogWarning
+		switch severity {
+		case gl.DEBUG_SEVERITY_HIGH:
+			level = LogError
+		case gl.DEBUG_SEVERITY_NOTIFICATION:
+			level = LogDebug
+		}
+		logf(level, "open gl debug (source 0x%x, type 0x%x, id %d, severity 0x%x): %s", source, gltype, id, severity, message)
+	}, nil)
+	return nil
+}
+
+// hasDebugOutput tells whether the current context supports debug output. The bindings
+// leave gl.DebugMessageCallback nil without it, calling it then crashes.
+func hasDebugOutput() bool {
+	var major, minor int32
+	gl.GetIntegerv(gl.MAJOR_VERSION, &major)
+	gl.GetIntegerv(gl.MINOR_VERSION, &minor)
+	if major > 4 || major == 4 && minor >= 3 {
+		return true
+	}
+	var n int32
+	gl.GetIntegerv(gl.NUM_EXTENSIONS, &n)
+	for i := range uint32(n) {
+		if gl.GoStr(gl.GetStringi(gl.EXTENSIONS, i)) == "GL_KHR_debug" {
+			return true
+		}
+	}
+	return false
+}
+
+// setClearColor sets the Open GL clear color to c, alpha-premultiplied like the gui.
+func setClearColor(c color.Color) {
+	r, g, b, a := c.RGBA()
+	gl.ClearColor(float32(r)/0xffff, float32(g)/0xffff, float32(b)/0xffff, float32(a)/0xffff)
+}
+
+// glState is the part of the Open GL state the gui compositing changes, so it can give it
+// back to the functions sent to the GL() channel untouched.
+type glState struct {
+	program, vao, activeTexture, texture int32
+	unpackBuffer                         int32
+	drawFramebuffer, readFramebuffer     int32
+
+	blend                              bool
+	srcRGB, dstRGB, srcAlpha, dstAlpha int32
+	depthTest, depthMask               bool
+	depthFunc                          int32
+	scissorTest, stencilTest           bool
+	framebufferSRGB                    bool
+	scissor, viewport                  [4]int32
+}
+
+// saveGLState reads the current state. The texture is the one bound to texture unit 0,
+// which is the unit the gui uses.
+func saveGLState() glState {
+	var s glState
+	gl.GetIntegerv(gl.CURRENT_PROGRAM, &s.program)
+	gl.GetIntegerv(gl.VERTEX_ARRAY_BINDING, &s.vao)
+	gl.GetIntegerv(gl.ACTIVE_TEXTURE, &s.activeTexture)
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.GetIntegerv(gl.TEXTURE_BINDING_2D, &s.texture)
+	gl.GetIntegerv(gl.PIXEL_UNPACK_BUFFER_BINDING, &s.unpackBuffer)
+	gl.GetIntegerv(gl.DRAW_FRAMEBUFFER_BINDING, &s.drawFramebuffer)
+	gl.GetIntegerv(gl.READ_FRAMEBUFFER_BINDING, &s.readFramebuffer)
+
+	s.blend = gl.IsEnabled(gl.BLEND)
+	gl.GetIntegerv(gl.BLEND_SRC_RGB, &s.srcRGB)
+	gl.GetIntegerv(gl.BLEND_DST_RGB, &s.dstRGB)
+	gl.GetIntegerv(gl.BLEND_SRC_ALPHA, &s.srcAlpha)
+	gl.GetIntegerv(gl.BLEND_DST_ALPHA, &s.dstAlpha)
+
+	s.depthTest = gl.IsEnabled(gl.DEPTH_TEST)
+	gl.GetBooleanv(gl.DEPTH_WRITEMASK, &s.depthMask)
+	gl.GetIntegerv(gl.DEPTH_FUNC, &s.depthFunc)
+
+	s.scissorTest = gl.IsEnabled(gl.SCISSOR_TEST)
+	s.stencilTest = gl.IsEnabled(gl.STENCIL_TEST)
+	s.framebufferSRGB = gl.IsEnabled(gl.FRAMEBUFFER_SRGB)
+	gl.GetIntegerv(gl.SCISSOR_BOX, &s.scissor[0])
+	gl.GetIntegerv(gl.VIEWPORT, &s.viewport[0])
+	return s
+}
+
+func setEnabled(capability uint32, enabled bool) {
+	if enabled {
+		gl.Enable(capability)
+	} else {
+		gl.Disable(capability)
+	}
+}
+
+// restore puts the saved state back.
+func (s glState) restore() {
+	gl.UseProgram(uint32(s.program))
+	gl.BindVertexArray(uint32(s.vao))
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindTexture(gl.TEXTURE_2D, uint32(s.texture))
+	gl.ActiveTexture(uint32(s.activeTexture))
+	gl.BindBuffer(gl.PIXEL_UNPACK_BUFFER, uint32(s.unpackBuffer))
+	gl.BindFramebuffer(gl.DRAW_FRAMEBUFFER, uint32(s.drawFramebuffer))
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, uint32(s.readFramebuffer))
+
+	setEnabled(gl.BLEND, s.blend)
+	gl.BlendFuncSeparate(uint32(s.srcRGB), uint32(s.dstRGB), uint32(s.srcAlpha), uint32(s.dstAlpha))
+
+	setEnabled(gl.DEPTH_TEST, s.depthTest)
+	gl.DepthMask(s.depthMask)
+	gl.DepthFunc(uint32(s.depthFunc))
+
+	setEnabled(gl.SCISSOR_TEST, s.scissorTest)
+	setEnabled(gl.STENCIL_TEST, s.stencilTest)
+	setEnabled(gl.FRAMEBUFFER_SRGB, s.framebufferSRGB)
+	gl.Scissor(s.scissor[0], s.scissor[1], s.scissor[2], s.scissor[3])
+	gl.Viewport(s.viewport[0], s.viewport[1], s.viewport[2], s.viewport[3])
+}
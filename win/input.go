@@ -0,0 +1,128 @@
+package win
+
+import (
+	"errors"
+	"image"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+// KeyPressed tells whether the key k is held down right now. For KeyShift, KeyCtrl and
+// KeyAlt either the left or the right key counts. It always returns false for KeyUnknown
+// and after the window got closed.
+//
+// It's meant for polling the keyboard every frame, e.g. in a game loop, instead of keeping
+// track of the KbDown and KbUp events.
+func (w *Win) KeyPressed(k Key) bool {
+	pressed := false
+	w.callMain(func() {
+		for key, wk := range keys {
+			if wk == k && w.w.GetKey(key) == glfw.Press {
+				pressed = true
+				return
+			}
+		}
+	})
+	return pressed
+}
+
+// MouseButtonPressed tells whether the mouse button b is held down right now. It always
+// returns false after the window got closed.
+func (w *Win) MouseButtonPressed(b Button) bool {
+	pressed := false
+	w.callMain(func() {
+		for button, wb := range buttons {
+			if wb == b && w.w.GetMouseButton(button) == glfw.Press {
+				pressed = true
+				return
+			}
+		}
+	})
+	return pressed
+}
+
+// CursorPos returns the position of the mouse cursor right now, in the same coordinates
+// as the mouse events. It returns the zero point after the window got closed.
+func (w *Win) CursorPos() image.Point {
+	var p image.Point
+	w.callMain(func() {
+		x, y := w.w.GetCursorPos()
+		fbw, fbh := w.w.GetFramebufferSize()
+		view, size := w.guiView(image.Pt(fbw, fbh))
+		p = toVirtual(image.Pt(int(x)*w.ratio, int(y)*w.ratio), view, size)
+	})
+	return p
+}
+
+// WarpCursor moves the mouse cursor to the point p, in the same coordinates as the mouse
+// events, e.g. to snap it onto a button. Depending on the platform a MoMove event to p
+// follows, like for a move by the user, but a captured cursor reports no motion for the
+// jump in MoMoveRel events. It does nothing if the window is closed.
+func (w *Win) WarpCursor(p image.Point) {
+	w.callMain(func() {
+		fbw, fbh := w.w.GetFramebufferSize()
+		view, size := w.guiView(image.Pt(fbw, fbh))
+		fp := fromVirtual(p, view, size)
+		x, y := float64(fp.X)/float64(w.ratio), float64(fp.Y)/float64(w.ratio)
+		w.w.SetCursorPos(x, y)
+		// the jump isn't a motion of the mouse
+		w.lastCursor = [2]float64{x, y}
+	})
+}
+
+// SetCursorCapture captures the mouse cursor or releases it again. A captured cursor is
+// hidden and can't leave the window, the mouse then moves it without limits and the window
+// produces MoMoveRel events telling how far. That's what a drag that shouldn't end at the
+// edge of the window or a camera controlled by the mouse needs.
+//
+// Where the platform supports it, the captured mouse reports raw motion, without the
+// acceleration of the OS. It does nothing if the window is closed.
+func (w *Win) SetCursorCapture(capture bool) {
+	w.callMain(func() {
+		w.captured = capture
+		if capture {
+			w.w.SetInputMode(glfw.CursorMode, glfw.CursorDisabled)
+		} else {
+			w.w.SetInputMode(glfw.CursorMode, glfw.CursorNormal)
+		}
+		if glfw.RawMouseMotionSupported() {
+			raw := glfw.False
+			if capture {
+				raw = glfw.True
+			}
+			w.w.SetInputMode(glfw.RawMouseMotion, raw)
+		}
+		// the motion starts from where the cursor is now
+		x, y := w.w.GetCursorPos()
+		w.lastCursor = [2]float64{x, y}
+	})
+}
+
+// SetRawMouseMotion turns raw mouse motion on or off, that's motion without the acceleration
+// of the OS, for a camera that should turn by the same angle for the same mouse movement.
+// It only has an effect while the cursor is captured, see SetCursorCapture, which turns it
+// on where supported. Call SetRawMouseMotion after capturing to turn it off again.
+//
+// It returns an error if the platform doesn't support raw mouse motion, or if the window is
+// closed.
+func (w *Win) SetRawMouseMotion(raw bool) error {
+	var supported bool
+	ok := w.callMain(func() {
+		supported = glfw.RawMouseMotionSupported()
+		if !supported {
+			return
+		}
+		mode := glfw.False
+		if raw {
+			mode = glfw.True
+		}
+		w.w.SetInputMode(glfw.RawMouseMotion, mode)
+	})
+	if !ok {
+		return errClosed
+	}
+	if !supported {
+		return errors.New("raw mouse motion not supported")
+	}
+	return nil
+}
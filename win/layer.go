@@ -0,0 +1,162 @@
+package win
+
+import (
+	"image"
+	"image/draw"
+	"sort"
+)
+
+// Layer is a separate gui surface of a window, with its own image and its own draw channel.
+//
+// Layers get composited together with the main drawing area of the window, the one behind
+// the Draw() channel of the window, in ascending z order. The main drawing area has z 0 and
+// goes below the layers with z 0. Layers are transparent until drawn onto, so a layer with
+// a modal dialog can go over the rest of the gui without clobbering it.
+type Layer struct {
+	name string
+	z    int
+	img  *image.RGBA
+	draw chan func(draw.Image) image.Rectangle
+	sync chan struct{} // see syncLayers
+	done chan struct{} // closed once the draw channel got closed
+}
+
+// Draw returns the draw channel of the layer. It works just like the Draw() channel of the
+// window. Closing it removes the layer from the window.
+func (l *Layer) Draw() chan<- func(draw.Image) image.Rectangle { return l.draw }
+
+// layerDraw is a drawing function sent to a layer.
+type layerDraw struct {
+	l *Layer
+	d func(draw.Image) image.Rectangle
+}
+
+// Layer returns the layer of the window with the given name, creating it if there's none
+// yet. The layer gets placed at the given z, even if it already existed. Layer returns nil
+// if the window is closed.
+func (w *Win) Layer(name string, z int) *Layer {
+	reply := make(chan *Layer, 1)
+	if !w.glCall(func() { reply <- w.layer(name, z) }) {
+		return nil
+	}
+	return <-reply
+}
+
+func (w *Win) layer(name string, z int) *Layer {
+	for _, l := range w.layers {
+		if l.name == name {
+			if l.z != z {
+				l.z = z
+				w.sortLayers()
+				w.dirty.add(l.img.Bounds())
+			}
+			return l
+		}
+	}
+	l := &Layer{
+		name: name,
+		z:    z,
+		draw: make(chan func(draw.Image) image.Rectangle),
+		sync: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	if w.noGui {
+		l.img = &image.RGBA{Rect: w.img.Bounds()}
+	} else {
+		l.img = image.NewRGBA(w.img.Bounds())
+	}
+	w.layers = append(w.layers, l)
+	w.sortLayers()
+	go w.forwardLayer(l)
+	return l
+}
+
+// forwardLayer sends the drawing functions of the layer over to the Open GL thread, until
+// the draw channel of the layer gets closed.
+func (w *Win) forwardLayer(l *Layer) {
+	defer close(l.done)
+	for {
+		select {
+		case d, ok := <-l.draw:
+			if !ok {
+				w.removeLayer(l)
+				return
+			}
+			select {
+			case w.layerDraws <- layerDraw{l, d}:
+			case <-w.finish:
+			}
+		case <-l.sync:
+			// everything received before got handed over
+		}
+	}
+}
+
+// syncLayers waits until the drawing functions sent to the layers before got handed over to
+// the Open GL thread, which applies them right away, so a function sent with glCall
+// afterwards runs after them.
+func (w *Win) syncLayers() {
+	reply := make(chan []*Layer, 1)
+	if !w.glCall(func() { reply <- append([]*Layer(nil), w.layers...) }) {
+		return
+	}
+	for _, l := range <-reply {
+		select {
+		case l.sync <- struct{}{}:
+		case <-l.done:
+		case <-w.finish:
+		}
+	}
+}
+
+// removeLayer removes the layer from the window.
+func (w *Win) removeLayer(l *Layer) {
+	w.glCall(func() {
+		for i := range w.layers {
+			if w.layers[i] == l {
+				w.layers = append(w.layers[:i], w.layers[i+1:]...)
+				w.dirty.add(l.img.Bounds())
+				break
+			}
+		}
+	})
+}
+
+func (w *Win) sortLayers() {
+	sort.SliceStable(w.layers, func(i, j int) bool { return w.layers[i].z < w.layers[j].z })
+}
+
+// composite returns the part r of the gui, with the main drawing area and all the layers
+// composited in z order.
+func (w *Win) composite(r image.Rectangle) *image.RGBA {
+	tmp := image.NewRGBA(r)
+	w.compositeInto(tmp, r)
+	return tmp
+}
+
+// compositeInto is like composite, but draws into dst, which has to cover r.
+func (w *Win) compositeInto(dst *image.RGBA, r image.Rectangle) {
+	op := draw.Src
+	put := func(img image.Image) {
+		draw.Draw(dst, r, img, r.Min, op)
+		op = draw.Over
+	}
+	main := false
+	for _, l := range w.layers {
+		if !main && l.z >= 0 {
+			put(w.img)
+			main = true
+		}
+		put(l.img)
+	}
+	if !main {
+		put(w.img)
+	}
+}
+
+// resizeRGBA returns a new image with the bounds r and the content of img.
+func resizeRGBA(img *image.RGBA, r image.Rectangle) *image.RGBA {
+	resized := image.NewRGBA(r)
+	draw.Draw(resized, img.Bounds(), img, img.Bounds().Min, draw.Src)
+	return resized
+}
@@ -0,0 +1,50 @@
+package win
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+// fakeGLThread handles the drawing functions of the layers and the functions of glCall like
+// the Open GL thread does, until the window finishes.
+func fakeGLThread(w *Win) {
+	for {
+		select {
+		case ld := <-w.layerDraws:
+			ld.d(ld.l.img)
+		case f := <-w.glCalls:
+			f()
+		case <-w.finish:
+			return
+		}
+	}
+}
+
+func TestLayerDrawBeforeFlush(t *testing.T) {
+	w := &Win{
+		img:        image.NewRGBA(image.Rect(0, 0, 100, 1)),
+		glCalls:    make(chan func()),
+		layerDraws: make(chan layerDraw),
+		finish:     make(chan struct{}),
+	}
+	l := w.layer("overlay", 1)
+	go fakeGLThread(w)
+	defer close(w.finish)
+
+	for i := 0; i < w.img.Bounds().Dx(); i++ {
+		l.Draw() <- func(dst draw.Image) image.Rectangle {
+			dst.Set(i, 0, color.White)
+			return image.Rect(i, 0, i+1, 1)
+		}
+
+		// what Flush does before putting everything on the screen
+		applied := make(chan bool, 1)
+		w.syncLayers()
+		w.glCall(func() { applied <- l.img.RGBAAt(i, 0) == (color.RGBA{0xff, 0xff, 0xff, 0xff}) })
+		if !<-applied {
+			t.Fatalf("layer draw %d not applied before the flush", i)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package win
+
+import (
+	"fmt"
+	"log"
+	"sync"
+)
+
+// Levels of the messages passed to the function set with SetLogger.
+const (
+	LogDebug   = "debug"
+	LogWarning = "warning"
+	LogError   = "error"
+)
+
+var (
+	loggerMu sync.Mutex
+	logger   func(level, msg string)
+)
+
+// SetLogger makes the package report its diagnostics, the problems it works around or can't
+// do anything about, to f instead of the standard log package. The level is one of
+// LogDebug, LogWarning and LogError. Setting nil goes back to the standard log package.
+//
+// The function gets called from the Open GL thread and the main thread, so it must not
+// block for long and must be safe for concurrent use.
+func SetLogger(f func(level, msg string)) {
+	loggerMu.Lock()
+	defer loggerMu.Unlock()
+	logger = f
+}
+
+// logf reports a diagnostic message to the function set with SetLogger.
+func logf(level, format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	loggerMu.Lock()
+	f := logger
+	loggerMu.Unlock()
+	if f == nil {
+		log.Printf("win: %s: %s", level, msg)
+		return
+	}
+	f(level, msg)
+}
@@ -0,0 +1,145 @@
+package win
+
+import (
+	"fmt"
+	"image"
+	"time"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+// RefreshRate returns the refresh rate in Hz of the monitor the window is on, the one with
+// the most of the window on it if it spans several. It returns 0 if it's unknown or the
+// window is closed.
+func (w *Win) RefreshRate() int {
+	rate := 0
+	w.callMain(func() {
+		rate = refreshRate(w.w)
+	})
+	return rate
+}
+
+// refreshRate returns the refresh rate of the monitor of the window, or 0 if it's unknown.
+// It must be called on the main thread.
+func refreshRate(win *glfw.Window) int {
+	m := monitorOf(win)
+	if m == nil {
+		return 0
+	}
+	mode := m.GetVideoMode()
+	if mode == nil {
+		return 0
+	}
+	return mode.RefreshRate
+}
+
+// monitorOf returns the monitor the window is on, or nil if there is none. A windowed
+// window is on the monitor with the biggest overlap, or on the primary one if it's off all
+// of them. It must be called on the main thread.
+func monitorOf(win *glfw.Window) *glfw.Monitor {
+	if m := win.GetMonitor(); m != nil {
+		// fullscreen
+		return m
+	}
+	x, y := win.GetPos()
+	width, height := win.GetSize()
+	r := image.Rect(x, y, x+width, y+height)
+
+	var best *glfw.Monitor
+	bestArea := 0
+	for _, m := range glfw.GetMonitors() {
+		mode := m.GetVideoMode()
+		if mode == nil {
+			continue
+		}
+		mx, my := m.GetPos()
+		overlap := r.Intersect(image.Rect(mx, my, mx+mode.Width, my+mode.Height))
+		if area := overlap.Dx() * overlap.Dy(); area > bestArea {
+			best, bestArea = m, area
+		}
+	}
+	if best == nil {
+		return glfw.GetPrimaryMonitor()
+	}
+	return best
+}
+
+// defaultRedrawRate is the rate of the updates of the screen, see RedrawRate.
+const defaultRedrawRate = 960
+
+// flushDelay returns how long the Open GL thread waits for more work before it puts the
+// changes on the screen. That's about 1/hz of a second, rounded to a whole multiple of the
+// refresh rate, so the updates line up with the refreshes of the monitor.
+func flushDelay(rate, hz int) time.Duration {
+	if hz <= 0 {
+		hz = defaultRedrawRate
+	}
+	if rate <= 0 {
+		return time.Second / time.Duration(hz)
+	}
+	n := (hz + rate - 1) / rate
+	return time.Second / time.Duration(n*rate)
+}
+
+// frameInterval returns the time between the frames at the refresh rate, assuming 60 Hz if
+// it's unknown.
+func frameInterval(rate int) time.Duration {
+	if rate <= 0 {
+		rate = 60
+	}
+	return time.Second / time.Duration(rate)
+}
+
+// VideoMode is a resolution, refresh rate and color depth a monitor supports.
+type VideoMode struct {
+	Width, Height                int
+	RefreshRate                  int
+	RedBits, GreenBits, BlueBits int
+}
+
+// VideoModes returns the video modes the monitor with the given index supports, e.g. for a
+// display settings menu. Index 0 is the primary monitor. It returns nil if there's no such
+// monitor.
+//
+// VideoModes must only be called while a window is open.
+func VideoModes(monitorIndex int) []VideoMode {
+	var modes []VideoMode
+	callMain(func() {
+		monitors := glfw.GetMonitors()
+		if monitorIndex < 0 || monitorIndex >= len(monitors) {
+			return
+		}
+		for _, m := range monitors[monitorIndex].GetVideoModes() {
+			modes = append(modes, videoMode(m))
+		}
+	})
+	return modes
+}
+
+func videoMode(m *glfw.VidMode) VideoMode {
+	return VideoMode{
+		Width:       m.Width,
+		Height:      m.Height,
+		RefreshRate: m.RefreshRate,
+		RedBits:     m.RedBits,
+		GreenBits:   m.GreenBits,
+		BlueBits:    m.BlueBits,
+	}
+}
+
+// fullscreenMonitor looks up the monitor and video mode for the Fullscreen option and sets
+// the size of the window to the one of the video mode. It must be called on the main thread,
+// after initializing glfw.
+func fullscreenMonitor(o *options) error {
+	monitors := glfw.GetMonitors()
+	if o.monitorIndex < 0 || o.monitorIndex >= len(monitors) {
+		return fmt.Errorf("no monitor %d, there are %d", o.monitorIndex, len(monitors))
+	}
+	o.monitor = monitors[o.monitorIndex]
+	if o.videoMode == (VideoMode{}) {
+		// the current, native mode
+		o.videoMode = videoMode(o.monitor.GetVideoMode())
+	}
+	o.width, o.height = o.videoMode.Width, o.videoMode.Height
+	return nil
+}
@@ -0,0 +1,58 @@
+package win
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// offscreenBuffer is the framebuffer object a window of NewOffscreen renders into. The
+// default framebuffer of a window that never gets shown has undefined content, so it can't
+// be read back reliably.
+type offscreenBuffer struct {
+	fbo   uint32
+	color uint32 // renderbuffer of the color attachment
+	depth uint32 // renderbuffer of the depth and stencil attachment
+	size  image.Point
+}
+
+// newOffscreenBuffer creates an offscreen buffer of the given size and leaves it bound.
+func newOffscreenBuffer(size image.Point) (*offscreenBuffer, error) {
+	b := &offscreenBuffer{}
+	gl.GenFramebuffers(1, &b.fbo)
+	gl.GenRenderbuffers(1, &b.color)
+	gl.GenRenderbuffers(1, &b.depth)
+	if err := b.resize(size); err != nil {
+		b.delete()
+		return nil, err
+	}
+	return b, nil
+}
+
+// resize reallocates the attachments of the buffer with the new size, dropping their
+// content, and leaves the buffer bound.
+func (b *offscreenBuffer) resize(size image.Point) error {
+	b.size = size
+	gl.BindRenderbuffer(gl.RENDERBUFFER, b.color)
+	gl.RenderbufferStorage(gl.RENDERBUFFER, gl.RGBA8, int32(size.X), int32(size.Y))
+	gl.BindRenderbuffer(gl.RENDERBUFFER, b.depth)
+	gl.RenderbufferStorage(gl.RENDERBUFFER, gl.DEPTH24_STENCIL8, int32(size.X), int32(size.Y))
+	gl.BindRenderbuffer(gl.RENDERBUFFER, 0)
+
+	gl.BindFramebuffer(gl.FRAMEBUFFER, b.fbo)
+	gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.RENDERBUFFER, b.color)
+	gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.DEPTH_STENCIL_ATTACHMENT, gl.RENDERBUFFER, b.depth)
+	if status := gl.CheckFramebufferStatus(gl.FRAMEBUFFER); status != gl.FRAMEBUFFER_COMPLETE {
+		return fmt.Errorf("offscreen framebuffer of %dx%d incomplete: 0x%x", size.X, size.Y, status)
+	}
+	return nil
+}
+
+// delete frees the buffer and its attachments.
+func (b *offscreenBuffer) delete() {
+	gl.DeleteFramebuffers(1, &b.fbo)
+	gl.DeleteRenderbuffers(1, &b.color)
+	gl.DeleteRenderbuffers(1, &b.depth)
+	b.fbo, b.color, b.depth = 0, 0, 0
+}
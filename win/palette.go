@@ -0,0 +1,38 @@
+package win
+
+import "image/color"
+
+// Named colors for drawing onto the gui, all opaque.
+var (
+	White  = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	Black  = color.RGBA{0x00, 0x00, 0x00, 0xff}
+	Gray   = color.RGBA{0x80, 0x80, 0x80, 0xff}
+	Red    = color.RGBA{0xe1, 0x57, 0x59, 0xff}
+	Orange = color.RGBA{0xf2, 0x8e, 0x2b, 0xff}
+	Yellow = color.RGBA{0xed, 0xc9, 0x48, 0xff}
+	Green  = color.RGBA{0x59, 0xa1, 0x4f, 0xff}
+	Teal   = color.RGBA{0x76, 0xb7, 0xb2, 0xff}
+	Blue   = color.RGBA{0x4e, 0x79, 0xa7, 0xff}
+	Purple = color.RGBA{0xb0, 0x7a, 0xa1, 0xff}
+	Pink   = color.RGBA{0xff, 0x9d, 0xa7, 0xff}
+	Brown  = color.RGBA{0x9c, 0x75, 0x5f, 0xff}
+)
+
+// Palette is a list of colors to pick from by index, e.g. one for each item of a chart.
+type Palette []color.RGBA
+
+// DefaultPalette is a set of colors that are easy to tell apart and go well together.
+var DefaultPalette = Palette{Blue, Orange, Red, Teal, Green, Yellow, Purple, Pink, Brown, Gray}
+
+// At returns the color at the index i, starting over at the beginning of the palette after
+// its end, so any index works, even a negative one. It returns Black for an empty palette.
+func (p Palette) At(i int) color.RGBA {
+	if len(p) == 0 {
+		return Black
+	}
+	i %= len(p)
+	if i < 0 {
+		i += len(p)
+	}
+	return p[i]
+}
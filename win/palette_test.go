@@ -0,0 +1,31 @@
+package win
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestPaletteAt(t *testing.T) {
+	p := Palette{Red, Green, Blue}
+	tests := []struct {
+		i    int
+		want color.RGBA
+	}{
+		{0, Red},
+		{2, Blue},
+		{3, Red},
+		{7, Green},
+		{-1, Blue},
+		{-3, Red},
+		{-4, Blue},
+	}
+	for _, tt := range tests {
+		if got := p.At(tt.i); got != tt.want {
+			t.Errorf("At(%d) = %v, want %v", tt.i, got, tt.want)
+		}
+	}
+
+	if got := (Palette{}).At(5); got != Black {
+		t.Errorf("At of an empty palette = %v, want Black", got)
+	}
+}
@@ -0,0 +1,56 @@
+package win
+
+import (
+	"image"
+	"unsafe"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// pixelBufferCount is the number of pixel buffers in the ring. While the driver is still
+// uploading from one buffer, the next frames get written into the others.
+const pixelBufferCount = 3
+
+// uploadPixelBuffer composites the part r of the gui into the next pixel buffer of the ring
+// and uploads it from there into the gui texture bound to TEXTURE_2D. It returns false if
+// the buffer couldn't be mapped, then nothing got uploaded.
+func (w *Win) uploadPixelBuffer(r image.Rectangle) bool {
+	pbo := w.pbos[w.pboNext]
+	w.pboNext = (w.pboNext + 1) % len(w.pbos)
+
+	size := r.Dx() * r.Dy() * 4
+	gl.BindBuffer(gl.PIXEL_UNPACK_BUFFER, pbo)
+	// orphan the old storage, so mapping doesn't wait for an upload still reading from it
+	gl.BufferData(gl.PIXEL_UNPACK_BUFFER, size, nil, gl.STREAM_DRAW)
+	ptr := gl.MapBufferRange(gl.PIXEL_UNPACK_BUFFER, 0, size, gl.MAP_WRITE_BIT|gl.MAP_INVALIDATE_BUFFER_BIT)
+	if ptr == nil {
+		logf(LogWarning, "mapping a pixel buffer failed, uploading without it")
+		gl.BindBuffer(gl.PIXEL_UNPACK_BUFFER, 0)
+		return false
+	}
+
+	w.compositeInto(&image.RGBA{
+		Pix:    unsafe.Slice((*byte)(ptr), size),
+		Stride: r.Dx() * 4,
+		Rect:   r,
+	}, r)
+
+	// the content of the buffer got lost, e.g. on a video mode change
+	if !gl.UnmapBuffer(gl.PIXEL_UNPACK_BUFFER) {
+		gl.BindBuffer(gl.PIXEL_UNPACK_BUFFER, 0)
+		return false
+	}
+
+	gl.TexSubImage2D(
+		gl.TEXTURE_2D,
+		0,
+		int32(r.Min.X),
+		int32(r.Min.Y),
+		int32(r.Dx()),
+		int32(r.Dy()),
+		gl.RGBA,
+		gl.UNSIGNED_BYTE,
+		gl.PtrOffset(0))
+	gl.BindBuffer(gl.PIXEL_UNPACK_BUFFER, 0)
+	return true
+}
@@ -0,0 +1,151 @@
+package win
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// Screenshot returns what the window shows, the Open GL content with the gui over it, in
+// pixels of the framebuffer. Drawing functions sent before get applied and put on the screen
+// first.
+//
+// With a transparent framebuffer, the pixels are alpha-premultiplied, just like the ones of
+// an *image.RGBA. It returns an error if the window is closed or reading fails.
+func (w *Win) Screenshot() (*image.RGBA, error) {
+	return w.screenshot(func(fb image.Point) image.Rectangle {
+		return image.Rectangle{Max: fb}
+	})
+}
+
+// ScreenshotRegion is like Screenshot, but only reads back the part r of the gui, which is
+// a lot cheaper for small parts, e.g. to capture just the parts that changed. The returned
+// image has the bounds of that part in the framebuffer, which are the same as r, unless
+// there is a virtual resolution or a GUIScale.
+func (w *Win) ScreenshotRegion(r image.Rectangle) (*image.RGBA, error) {
+	return w.screenshot(func(fb image.Point) image.Rectangle {
+		return w.toFramebuffer(r.Intersect(w.img.Bounds())).Intersect(image.Rectangle{Max: fb})
+	})
+}
+
+// screenshot reads back the part of the framebuffer that region picks for its size.
+func (w *Win) screenshot(region func(fb image.Point) image.Rectangle) (*image.RGBA, error) {
+	type result struct {
+		img *image.RGBA
+		err error
+	}
+	reply := make(chan result, 1)
+	w.syncLayers()
+	ok := w.glCall(func() {
+		w.flushPending()
+		wid, hei := w.w.GetFramebufferSize()
+		img, err := readPixels(w.renderTarget(), region(image.Pt(wid, hei)), hei)
+		reply <- result{img, err}
+	})
+	if !ok {
+		return nil, errClosed
+	}
+	res := <-reply
+	return res.img, res.err
+}
+
+// PixelAt returns the color the window shows at the point p of the gui, the Open GL content
+// with the gui over it, e.g. for an eyedropper tool. Drawing functions sent before get
+// applied and put on the screen first.
+//
+// It returns an error if p is outside the gui, the window is closed or reading fails.
+func (w *Win) PixelAt(p image.Point) (color.RGBA, error) {
+	type result struct {
+		c   color.RGBA
+		err error
+	}
+	reply := make(chan result, 1)
+	w.syncLayers()
+	ok := w.glCall(func() {
+		w.flushPending()
+		if !p.In(w.img.Bounds()) {
+			reply <- result{err: fmt.Errorf("point %v outside of the gui %v", p, w.img.Bounds())}
+			return
+		}
+		_, hei := w.w.GetFramebufferSize()
+		fp := w.toFramebuffer(image.Rectangle{Min: p, Max: p.Add(image.Pt(1, 1))}).Min
+		img, err := readPixels(w.renderTarget(), image.Rectangle{Min: fp, Max: fp.Add(image.Pt(1, 1))}, hei)
+		if err != nil {
+			reply <- result{err: err}
+			return
+		}
+		reply <- result{c: img.RGBAAt(fp.X, fp.Y)}
+	})
+	if !ok {
+		return color.RGBA{}, errClosed
+	}
+	res := <-reply
+	return res.c, res.err
+}
+
+// Flush waits until the window has applied all drawing functions, including the ones sent to
+// layers, and Open GL functions sent before and put the result on the screen, instead of
+// with the next update. Everything sent afterwards comes after it, so a Screenshot following
+// a Flush reliably shows what got drawn before, e.g. in a test.
+//
+// It does nothing if the window is closed.
+func (w *Win) Flush() {
+	done := make(chan struct{})
+	w.syncLayers()
+	ok := w.glCall(func() {
+		w.flushPending()
+		gl.Finish()
+		close(done)
+	})
+	if ok {
+		<-done
+	}
+}
+
+// flushPending puts everything that waits for the next update on the screen right away.
+func (w *Win) flushPending() {
+	w.resizePending()
+	if len(w.queue) > 0 || len(w.dirty) > 0 {
+		w.present()
+		w.dirty = w.dirty[:0]
+	}
+}
+
+// readPixels reads the part r of the framebuffer object fbo, or of the framebuffer of the
+// window for 0, which is hei pixels high. Both r and the returned image have the origin in
+// the top-left corner, like the gui, not in the bottom-left one like Open GL.
+func readPixels(fbo uint32, r image.Rectangle, hei int) (*image.RGBA, error) {
+	img := image.NewRGBA(r)
+	if r.Empty() {
+		return img, nil
+	}
+	var prev int32
+	gl.GetIntegerv(gl.READ_FRAMEBUFFER_BINDING, &prev)
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, fbo)
+	defer gl.BindFramebuffer(gl.READ_FRAMEBUFFER, uint32(prev))
+	// rows of RGBA pixels are always 4 byte aligned, as the default pack alignment wants
+	gl.ReadPixels(
+		int32(r.Min.X),
+		int32(hei-r.Max.Y),
+		int32(r.Dx()),
+		int32(r.Dy()),
+		gl.RGBA,
+		gl.UNSIGNED_BYTE,
+		gl.Ptr(img.Pix))
+	if err := CheckGLError("read pixels"); err != nil {
+		return nil, err
+	}
+
+	// Open GL returns the bottom row first
+	row := make([]byte, img.Stride)
+	for top, bottom := 0, r.Dy()-1; top < bottom; top, bottom = top+1, bottom-1 {
+		a := img.Pix[top*img.Stride : (top+1)*img.Stride]
+		b := img.Pix[bottom*img.Stride : (bottom+1)*img.Stride]
+		copy(row, a)
+		copy(a, b)
+		copy(b, row)
+	}
+	return img, nil
+}
@@ -0,0 +1,55 @@
+package win
+
+import (
+	"runtime"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+// SharedContext is an Open GL context sharing its objects, like textures and buffers, with
+// the context of a window. It lets another goroutine load resources without blocking the
+// Open GL thread of the window.
+type SharedContext struct {
+	w *glfw.Window
+}
+
+// NewSharedContext creates a context sharing its objects with the context of the window. It
+// belongs to a hidden window of its own, with the same context version and profile.
+func (w *Win) NewSharedContext() (SharedContext, error) {
+	var (
+		shared *glfw.Window
+		err    error
+	)
+	ok := w.callMain(func() {
+		glfw.DefaultWindowHints()
+		contextHints(w.glMajor, w.glMinor, w.compatProfile)
+		glfw.WindowHint(glfw.Visible, glfw.False)
+		shared, err = glfw.CreateWindow(1, 1, "", nil, w.w)
+	})
+	if !ok {
+		return SharedContext{}, errClosed
+	}
+	if err != nil {
+		return SharedContext{}, err
+	}
+	return SharedContext{shared}, nil
+}
+
+// MakeCurrent makes the context current on the calling goroutine and locks the goroutine to
+// its OS thread, as Open GL contexts belong to threads. All the Open GL calls using the
+// context must happen on that goroutine, until Done.
+func (sc SharedContext) MakeCurrent() {
+	runtime.LockOSThread()
+	sc.w.MakeContextCurrent()
+}
+
+// Done waits for all the Open GL commands sent through the context to finish, so the window
+// can use the objects they created, and destroys the context. It must be called on the
+// goroutine MakeCurrent was called on, which then gets unlocked from its OS thread.
+func (sc SharedContext) Done() {
+	gl.Finish()
+	glfw.DetachCurrentContext()
+	runtime.UnlockOSThread()
+	callMain(sc.w.Destroy)
+}
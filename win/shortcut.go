@@ -0,0 +1,182 @@
+package win
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bbeni/guiGL"
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+// Shortcut is a key chord, a key pressed while holding down exactly the modifier keys Mod,
+// like Ctrl+Shift+S.
+//
+// Keys with a Key constant are identified by the Key field. All others, like letters, have
+// Key set to KeyUnknown and are identified by their label in the current keyboard layout,
+// the Name field, so "ctrl+z" is Ctrl and the key labeled Z on QWERTY as well as on AZERTY.
+type Shortcut struct {
+	Mod  Modifier
+	Key  Key
+	Name string
+
+	// Repeat makes the shortcut also match when its key gets repeated by holding it down.
+	Repeat bool
+}
+
+var modifierNames = map[string]Modifier{
+	"shift":   ModShift,
+	"ctrl":    ModCtrl,
+	"control": ModCtrl,
+	"alt":     ModAlt,
+	"option":  ModAlt,
+	"super":   ModSuper,
+	"cmd":     ModSuper,
+	"meta":    ModSuper,
+}
+
+// ParseShortcut parses a chord like "ctrl+shift+s": any number of modifiers, then the key,
+// separated by "+". Case doesn't matter. The modifiers are shift, ctrl (or control), alt (or
+// option) and super (or cmd, meta). The key is one of the names of the Key constants, like
+// "pageup", or a single character, like "s" or "1".
+func ParseShortcut(chord string) (Shortcut, error) {
+	var s Shortcut
+	parts := strings.Split(strings.ToLower(chord), "+")
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			return Shortcut{}, fmt.Errorf("invalid shortcut %q", chord)
+		}
+		if i < len(parts)-1 {
+			m, ok := modifierNames[part]
+			if !ok {
+				return Shortcut{}, fmt.Errorf("unknown modifier %q in shortcut %q", part, chord)
+			}
+			s.Mod |= m
+			continue
+		}
+		for k, name := range keyNames {
+			if name == part && Key(k) != KeyUnknown {
+				s.Key = Key(k)
+				return s, nil
+			}
+		}
+		if utf8.RuneCountInString(part) != 1 {
+			return Shortcut{}, fmt.Errorf("unknown key %q in shortcut %q", part, chord)
+		}
+		s.Key, s.Name = KeyUnknown, part
+	}
+	return s, nil
+}
+
+// String returns the shortcut in the form ParseShortcut takes, e.g. "ctrl+shift+s".
+func (s Shortcut) String() string {
+	var parts []string
+	for _, m := range []struct {
+		mod  Modifier
+		name string
+	}{{ModCtrl, "ctrl"}, {ModAlt, "alt"}, {ModShift, "shift"}, {ModSuper, "super"}} {
+		if s.Mod&m.mod != 0 {
+			parts = append(parts, m.name)
+		}
+	}
+	if s.Key == KeyUnknown {
+		parts = append(parts, s.Name)
+	} else {
+		parts = append(parts, s.Key.String())
+	}
+	return strings.Join(parts, "+")
+}
+
+// Match reports whether the event is a KbDown, or a KbRepeat if Repeat is set, of the
+// shortcut. Matching shortcuts identified by Name looks up the label of the key, so Match
+// must only be called while a window is open.
+func (s Shortcut) Match(e gui.Event) bool {
+	var (
+		k        Key
+		scancode int
+		mod      Modifier
+	)
+	switch e := e.(type) {
+	case KbDown:
+		k, scancode, mod = e.Key, e.Scancode, e.Mod
+	case KbRepeat:
+		if !s.Repeat {
+			return false
+		}
+		k, scancode, mod = e.Key, e.Scancode, e.Mod
+	default:
+		return false
+	}
+	if mod != s.Mod || k != s.Key {
+		return false
+	}
+	return k != KeyUnknown || strings.ToLower(KeyName(scancode)) == s.Name
+}
+
+// matchKey is Match for the key callback, on the main thread.
+func (s Shortcut) matchKey(k Key, key glfw.Key, scancode int, action glfw.Action, mod Modifier) bool {
+	if action == glfw.Release || (action == glfw.Repeat && !s.Repeat) {
+		return false
+	}
+	if mod != s.Mod || k != s.Key {
+		return false
+	}
+	return k != KeyUnknown || strings.ToLower(glfw.GetKeyName(key, scancode)) == s.Name
+}
+
+type shortcutHandler struct {
+	s  Shortcut
+	fn func()
+}
+
+// shortcutCall queues a call of a shortcut function, see AddShortcut.
+type shortcutCall func()
+
+func (sc shortcutCall) String() string { return "shortcut" }
+
+// OnShortcut makes the window call fn whenever the chord gets pressed, see ParseShortcut for
+// its format. It panics if the chord is invalid, like regexp.MustCompile, as chords are
+// usually fixed in the code. Use ParseShortcut and AddShortcut for chords from elsewhere.
+//
+// The key events of the chord still get sent to the Events() channel.
+func (w *Win) OnShortcut(chord string, fn func()) {
+	s, err := ParseShortcut(chord)
+	if err != nil {
+		panic(err)
+	}
+	w.AddShortcut(s, fn)
+}
+
+// AddShortcut makes the window call fn whenever the shortcut gets pressed. It only fires on
+// key repeat if Repeat is set.
+//
+// The functions of all shortcuts get called one after another, in the order the shortcuts
+// got pressed, on a goroutine of their own. That means fn must synchronize with the rest of
+// the app, e.g. by sending to a channel the event loop also selects on.
+//
+// It does nothing if the window is closed.
+func (w *Win) AddShortcut(s Shortcut, fn func()) {
+	w.callMain(func() {
+		if w.shortcutCalls == nil {
+			calls, queue := gui.MakeEventsChan()
+			w.shortcutCalls = queue
+			go func() {
+				for call := range calls {
+					call.(shortcutCall)()
+				}
+			}()
+		}
+		w.shortcuts = append(w.shortcuts, shortcutHandler{s, fn})
+	})
+}
+
+// callShortcuts queues the functions of the shortcuts matching a key event. It must be
+// called on the main thread.
+func (w *Win) callShortcuts(k Key, key glfw.Key, scancode int, action glfw.Action, mod Modifier) {
+	for _, h := range w.shortcuts {
+		if h.s.matchKey(k, key, scancode, action, mod) {
+			w.shortcutCalls <- shortcutCall(h.fn)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package win
+
+import "testing"
+
+func TestParseShortcut(t *testing.T) {
+	tests := []struct {
+		chord  string
+		want   Shortcut
+		string string
+	}{
+		{"s", Shortcut{Key: KeyUnknown, Name: "s"}, "s"},
+		{"ctrl+z", Shortcut{Mod: ModCtrl, Key: KeyUnknown, Name: "z"}, "ctrl+z"},
+		{"Shift+Ctrl+S", Shortcut{Mod: ModCtrl | ModShift, Key: KeyUnknown, Name: "s"}, "ctrl+shift+s"},
+		{"control + option + 1", Shortcut{Mod: ModCtrl | ModAlt, Key: KeyUnknown, Name: "1"}, "ctrl+alt+1"},
+		{"cmd+pageup", Shortcut{Mod: ModSuper, Key: KeyPageUp}, "super+pageup"},
+		{"escape", Shortcut{Key: KeyEscape}, "escape"},
+		{"alt+shift", Shortcut{Mod: ModAlt, Key: KeyShift}, "alt+shift"},
+	}
+	for _, tt := range tests {
+		s, err := ParseShortcut(tt.chord)
+		if err != nil {
+			t.Errorf("ParseShortcut(%q): %v", tt.chord, err)
+			continue
+		}
+		if s != tt.want {
+			t.Errorf("ParseShortcut(%q) = %+v, want %+v", tt.chord, s, tt.want)
+		}
+		if got := s.String(); got != tt.string {
+			t.Errorf("ParseShortcut(%q).String() = %q, want %q", tt.chord, got, tt.string)
+		}
+		// String gives the form ParseShortcut takes
+		if again, err := ParseShortcut(s.String()); err != nil || again != s {
+			t.Errorf("ParseShortcut(%q) = %+v, %v, want %+v", s.String(), again, err, s)
+		}
+	}
+}
+
+func TestParseShortcutInvalid(t *testing.T) {
+	for _, chord := range []string{"", "+", "ctrl+", "ctrl++s", "hyper+s", "ctrl+foo", "s+ctrl"} {
+		if s, err := ParseShortcut(chord); err == nil {
+			t.Errorf("ParseShortcut(%q) = %+v, want an error", chord, s)
+		}
+	}
+}
+
+func TestShortcutMatch(t *testing.T) {
+	s := Shortcut{Mod: ModCtrl, Key: KeyPageUp}
+	if !s.Match(KbDown{Key: KeyPageUp, Mod: ModCtrl}) {
+		t.Error("no match for the chord")
+	}
+	if s.Match(KbDown{Key: KeyPageUp, Mod: ModCtrl | ModShift}) {
+		t.Error("match with another modifier held down too")
+	}
+	if s.Match(KbDown{Key: KeyPageDown, Mod: ModCtrl}) {
+		t.Error("match for another key")
+	}
+	if s.Match(KbUp{Key: KeyPageUp, Mod: ModCtrl}) {
+		t.Error("match for releasing the key")
+	}
+	if s.Match(KbRepeat{Key: KeyPageUp, Mod: ModCtrl}) {
+		t.Error("match for a repeat without Repeat")
+	}
+	s.Repeat = true
+	if !s.Match(KbRepeat{Key: KeyPageUp, Mod: ModCtrl}) {
+		t.Error("no match for a repeat with Repeat")
+	}
+}
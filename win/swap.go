@@ -0,0 +1,42 @@
+package win
+
+import "github.com/go-gl/glfw/v3.3/glfw"
+
+// SwapMode tells how swapping the buffers waits for the refresh of the monitor.
+type SwapMode int
+
+// List of all swap modes.
+const (
+	// SwapImmediate swaps right away, which has the lowest latency, but can tear.
+	SwapImmediate SwapMode = 0
+
+	// SwapVSync waits for the next refresh of the monitor, so it never tears.
+	SwapVSync SwapMode = 1
+
+	// SwapAdaptive waits for the next refresh like SwapVSync, unless the frame is late
+	// already, then it swaps right away and tears instead of stuttering. Where the driver
+	// doesn't support it, SwapVSync gets used instead.
+	SwapAdaptive SwapMode = -1
+)
+
+// SetSwapMode changes the swap mode of the window and returns the one it ended up using,
+// which is SwapVSync when asking for SwapAdaptive without support from the driver. It does
+// nothing and returns m if the window is closed.
+func (w *Win) SetSwapMode(m SwapMode) SwapMode {
+	reply := make(chan SwapMode, 1)
+	if !w.glCall(func() { reply <- setSwapMode(m) }) {
+		return m
+	}
+	return <-reply
+}
+
+// setSwapMode sets the swap interval of the current context, falling back from adaptive to
+// regular vsync if the swap control tear extension is missing.
+func setSwapMode(m SwapMode) SwapMode {
+	if m == SwapAdaptive && !glfw.ExtensionSupported("GLX_EXT_swap_control_tear") &&
+		!glfw.ExtensionSupported("WGL_EXT_swap_control_tear") {
+		m = SwapVSync
+	}
+	glfw.SwapInterval(int(m))
+	return m
+}
@@ -0,0 +1,38 @@
+package win
+
+import "github.com/go-gl/gl/v3.3-core/gl"
+
+// SetRenderTarget makes the window render into the framebuffer object fbo instead of the
+// framebuffer of the window, e.g. to apply post-processing effects to everything before
+// putting it on the screen yourself. SetRenderTarget(0) goes back to the framebuffer of the
+// window, or to the framebuffer object of NewOffscreen.
+//
+// The target gets bound before each function sent to the GL() channel or to Update runs and
+// the gui gets composited into it. It has to have the size of the framebuffer of the window,
+// so resize it in an OnResize callback. It does nothing if the window is closed.
+func (w *Win) SetRenderTarget(fbo uint32) {
+	w.glCall(func() {
+		w.target = fbo
+		gl.BindFramebuffer(gl.FRAMEBUFFER, w.renderTarget())
+	})
+}
+
+// renderTarget returns the framebuffer object the window renders into: the one set with
+// SetRenderTarget, the one of NewOffscreen, or 0 for the framebuffer of the window.
+func (w *Win) renderTarget() uint32 {
+	if w.target != 0 {
+		return w.target
+	}
+	if w.offscreenBuf != nil {
+		return w.offscreenBuf.fbo
+	}
+	return 0
+}
+
+// bindTarget binds the render target before running the Open GL functions, see
+// renderTarget. Without one, it leaves whatever the functions bound themselves.
+func (w *Win) bindTarget() {
+	if t := w.renderTarget(); t != 0 {
+		gl.BindFramebuffer(gl.FRAMEBUFFER, t)
+	}
+}
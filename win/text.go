@@ -0,0 +1,55 @@
+package win
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// LoadFont parses the TrueType font data and returns a face of it with the size given in
+// pixels of the drawing area, for drawing text with DrawString. Use the LoadFont method of
+// the window to give the size in screen coordinates, so text has the same physical size on
+// a hiDPI display.
+//
+// The face is not safe for concurrent use, e.g. by drawing functions and the goroutine
+// measuring text for the layout at the same time.
+func LoadFont(data []byte, size float64) (font.Face, error) {
+	f, err := truetype.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	return truetype.NewFace(f, &truetype.Options{
+		Size:    size,
+		Hinting: font.HintingFull,
+	}), nil
+}
+
+// LoadFont is like the LoadFont function, but scales the size by the ContentScale of the
+// window, so the text looks the same size on a regular and on a hiDPI display.
+func (w *Win) LoadFont(data []byte, size float64) (font.Face, error) {
+	return LoadFont(data, size*w.ContentScale())
+}
+
+// DrawString draws the text s onto dst with the face and the color c, with the start of
+// its baseline at the point at. It returns the part of dst that changed, for returning from
+// a drawing function.
+func DrawString(dst draw.Image, face font.Face, at image.Point, s string, c color.Color) image.Rectangle {
+	d := &font.Drawer{
+		Dst:  dst,
+		Src:  image.NewUniform(c),
+		Face: face,
+		Dot:  fixed.P(at.X, at.Y),
+	}
+	b, _ := d.BoundString(s)
+	d.DrawString(s)
+	return image.Rect(
+		b.Min.X.Floor(),
+		b.Min.Y.Floor(),
+		b.Max.X.Ceil(),
+		b.Max.Y.Ceil(),
+	).Intersect(dst.Bounds())
+}
@@ -0,0 +1,80 @@
+package win
+
+import (
+	"errors"
+	"image"
+	"image/draw"
+	"unsafe"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// NewTextureFromImage creates an Open GL texture with the content of img, e.g. for the
+// content drawn in functions sent to the GL() channel. The texture filters linearly and
+// clamps to the edge. The top row of img ends up at the texture coordinate t=0.
+//
+// Any image works, images other than an *image.RGBA without gaps between the rows get
+// converted first. Like all images of the image package, the pixels are taken as alpha-
+// premultiplied. The texture stays bound to the active texture unit.
+//
+// It returns an error if img is empty or the upload fails. It must be called on the Open GL
+// thread, i.e. from a function sent to the GL() channel.
+func NewTextureFromImage(img image.Image) (uint32, error) {
+	if img.Bounds().Empty() {
+		return 0, errors.New("empty image")
+	}
+	var texture uint32
+	gl.GenTextures(1, &texture)
+	gl.BindTexture(gl.TEXTURE_2D, texture)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	uploadTexture(img)
+	if err := CheckGLError("new texture"); err != nil {
+		gl.DeleteTextures(1, &texture)
+		return 0, err
+	}
+	return texture, nil
+}
+
+// UpdateTexture replaces the content of the texture tex with img, which may have another
+// size than before. See NewTextureFromImage for which images work.
+//
+// It must be called on the Open GL thread, i.e. from a function sent to the GL() channel.
+// The texture stays bound to the active texture unit.
+func UpdateTexture(tex uint32, img image.Image) {
+	gl.BindTexture(gl.TEXTURE_2D, tex)
+	uploadTexture(img)
+}
+
+// uploadTexture uploads img to the texture bound to the active texture unit.
+func uploadTexture(img image.Image) {
+	rgba := toRGBA(img)
+	var pix unsafe.Pointer
+	if len(rgba.Pix) > 0 {
+		pix = gl.Ptr(rgba.Pix)
+	}
+	gl.TexImage2D(
+		gl.TEXTURE_2D,
+		0,
+		gl.RGBA,
+		int32(rgba.Rect.Dx()),
+		int32(rgba.Rect.Dy()),
+		0,
+		gl.RGBA,
+		gl.UNSIGNED_BYTE,
+		pix)
+}
+
+// toRGBA returns img as an *image.RGBA with the rows right after each other, the layout Open
+// GL takes. It only copies the pixels if img doesn't have that layout already.
+func toRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok && rgba.Stride == rgba.Rect.Dx()*4 {
+		return rgba
+	}
+	b := img.Bounds()
+	rgba := image.NewRGBA(image.Rectangle{Max: b.Size()})
+	draw.Draw(rgba, rgba.Bounds(), img, b.Min, draw.Src)
+	return rgba
+}
@@ -0,0 +1,34 @@
+package win
+
+import (
+	"sync"
+	"time"
+)
+
+// Ticker makes the window produce a Tick event every d, through the Events() channel like
+// all the other events, e.g. to drive an animation. Calling the returned function stops it,
+// closing the window stops it too.
+func (w *Win) Ticker(d time.Duration) (stop func()) {
+	done := make(chan struct{})
+	var once sync.Once
+	go func() {
+		t := time.NewTicker(d)
+		defer t.Stop()
+		for {
+			select {
+			case now := <-t.C:
+				// events only get sent from the main thread, which also closes the channel
+				w.callMain(func() {
+					w.eventsIn <- Tick{now}
+				})
+			case <-done:
+				return
+			case <-w.finish:
+				return
+			}
+		}
+	}()
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
@@ -0,0 +1,138 @@
+package win
+
+import (
+	"image"
+	"math"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// letterbox returns the largest rectangle with the aspect ratio of the virtual resolution,
+// that fits centered into a framebuffer of size fb. Without a virtual resolution, it's the
+// whole framebuffer.
+func letterbox(fb, virtual image.Point) image.Rectangle {
+	if virtual == (image.Point{}) {
+		return image.Rectangle{Max: fb}
+	}
+	width, height := fb.X, virtual.Y*fb.X/virtual.X
+	if height > fb.Y {
+		width, height = virtual.X*fb.Y/virtual.Y, fb.Y
+	}
+	min := image.Pt((fb.X-width)/2, (fb.Y-height)/2)
+	return image.Rectangle{Min: min, Max: min.Add(image.Pt(width, height))}
+}
+
+// letterboxBars returns the parts of a framebuffer of size fb not covered by view.
+func letterboxBars(fb image.Point, view image.Rectangle) []image.Rectangle {
+	var bars []image.Rectangle
+	if view.Min.X > 0 {
+		bars = append(bars, image.Rect(0, 0, view.Min.X, fb.Y), image.Rect(view.Max.X, 0, fb.X, fb.Y))
+	}
+	if view.Min.Y > 0 {
+		bars = append(bars, image.Rect(0, 0, fb.X, view.Min.Y), image.Rect(0, view.Max.Y, fb.X, fb.Y))
+	}
+	return bars
+}
+
+// guiSize returns the size of the gui for a framebuffer of size fb: the virtual resolution,
+// the framebuffer scaled down by the GUIScale, or the framebuffer itself.
+func (w *Win) guiSize(fb image.Point) image.Point {
+	switch {
+	case w.virtual != (image.Point{}):
+		return w.virtual
+	case w.guiScale != 0:
+		return image.Pt(
+			int(math.Ceil(float64(fb.X)/w.guiScale)),
+			int(math.Ceil(float64(fb.Y)/w.guiScale)),
+		)
+	}
+	return fb
+}
+
+// guiView returns the part of a framebuffer of size fb the gui gets scaled onto, and the
+// size of the gui. The size is the zero point if the gui isn't scaled. With a GUIScale, the
+// view covers the whole framebuffer and may stick out a bit on the right and the bottom,
+// so every pixel of the gui covers the same number of pixels of the framebuffer.
+func (w *Win) guiView(fb image.Point) (view image.Rectangle, size image.Point) {
+	switch {
+	case w.virtual != (image.Point{}):
+		return letterbox(fb, w.virtual), w.virtual
+	case w.guiScale != 0:
+		size = w.guiSize(fb)
+		return image.Rect(
+			0,
+			0,
+			int(math.Round(float64(size.X)*w.guiScale)),
+			int(math.Round(float64(size.Y)*w.guiScale)),
+		), size
+	}
+	return image.Rectangle{Max: fb}, image.Point{}
+}
+
+// toVirtual maps the point p in a framebuffer to the gui of the given size scaled onto the
+// view, see guiView.
+func toVirtual(p image.Point, view image.Rectangle, size image.Point) image.Point {
+	if size == (image.Point{}) || view.Empty() {
+		return p
+	}
+	return image.Pt(
+		(p.X-view.Min.X)*size.X/view.Dx(),
+		(p.Y-view.Min.Y)*size.Y/view.Dy(),
+	)
+}
+
+// fromVirtual maps the point p of the gui of the given size to the framebuffer, the
+// reverse of toVirtual.
+func fromVirtual(p image.Point, view image.Rectangle, size image.Point) image.Point {
+	if size == (image.Point{}) {
+		return p
+	}
+	return image.Pt(
+		view.Min.X+p.X*view.Dx()/size.X,
+		view.Min.Y+p.Y*view.Dy()/size.Y,
+	)
+}
+
+// toVirtualF is toVirtual for a point with fractional coordinates.
+func toVirtualF(x, y float64, view image.Rectangle, size image.Point) (float64, float64) {
+	if size == (image.Point{}) || view.Empty() {
+		return x, y
+	}
+	return (x - float64(view.Min.X)) * float64(size.X) / float64(view.Dx()),
+		(y - float64(view.Min.Y)) * float64(size.Y) / float64(view.Dy())
+}
+
+// toFramebuffer maps the rectangle r of the gui image to the framebuffer, rounding outwards.
+func (w *Win) toFramebuffer(r image.Rectangle) image.Rectangle {
+	if w.virtual == (image.Point{}) && w.guiScale == 0 {
+		return r
+	}
+	size := w.img.Bounds().Size()
+	v, vx, vy := w.view, size.X, size.Y
+	if vx == 0 || vy == 0 {
+		return image.Rectangle{}
+	}
+	return image.Rect(
+		v.Min.X+r.Min.X*v.Dx()/vx,
+		v.Min.Y+r.Min.Y*v.Dy()/vy,
+		v.Min.X+(r.Max.X*v.Dx()+vx-1)/vx,
+		v.Min.Y+(r.Max.Y*v.Dy()+vy-1)/vy,
+	)
+}
+
+// clearLetterbox fills the bars around the gui with the letterbox color. It leaves the clear
+// color as it was, but not the scissor box.
+func (w *Win) clearLetterbox(fb image.Point) {
+	bars := letterboxBars(fb, w.view)
+	if len(bars) == 0 {
+		return
+	}
+	var cc [4]float32
+	gl.GetFloatv(gl.COLOR_CLEAR_VALUE, &cc[0])
+	setClearColor(w.letterboxColor)
+	for _, bar := range bars {
+		gl.Scissor(int32(bar.Min.X), int32(fb.Y-bar.Max.Y), int32(bar.Dx()), int32(bar.Dy()))
+		gl.Clear(gl.COLOR_BUFFER_BIT)
+	}
+	gl.ClearColor(cc[0], cc[1], cc[2], cc[3])
+}
@@ -1,22 +1,31 @@
 package win
 
 import (
+	"context"
 	"image"
 	"image/draw"
 	"image/color"
 
 	"runtime"
+	"sort"
+	"sync/atomic"
 	"time"
 	"strings"
+	"errors"
 	"fmt"
+	"os"
 
 	"github.com/bbeni/guiGL"
 
 	"github.com/faiface/mainthread"
-	"github.com/go-gl/gl/v4.2-core/gl"
+	"github.com/go-gl/gl/v3.3-core/gl"
 	"github.com/go-gl/glfw/v3.3/glfw"
 )
 
+var errClosed = errors.New("window closed")
+
+var _ gui.Env = (*Win)(nil)
+
 // Option is a functional option to the window constructor New.
 type Option func(*options)
 
@@ -26,6 +35,35 @@ type options struct {
 	resizable     bool
 	borderless    bool
 	maximized     bool
+	floating      bool
+	transparent   bool
+	virtual       image.Point
+	letterbox     color.Color
+	noGui         bool
+	opacity       float32
+	glMajor       int
+	glMinor       int
+	compatProfile bool
+	samples       int
+	depthBits     int
+	stencilBits   int
+	pixelBuffers  bool
+	hidden        bool
+	offscreen     bool
+	swap          *SwapMode
+	singleBuffer  bool
+	coalesce      bool
+	fullscreen    bool
+	monitorIndex  int
+	videoMode     VideoMode
+	monitor       *glfw.Monitor // looked up from monitorIndex
+	nearest       bool
+	fullUpload    float64
+	centered      bool
+	srgb          bool
+	whenUnfocused bool
+	guiScale      float64
+	redrawRate    int
 }
 
 // Title option sets the title (caption) of the window.
@@ -64,31 +102,351 @@ func Maximized() Option {
 	}
 }
 
+// Floating option makes the window stay on top of other windows. It combines with the
+// Borderless option for overlay windows.
+func Floating() Option {
+	return func(o *options) {
+		o.floating = true
+	}
+}
+
+// Centered option opens the window in the middle of the work area of the primary monitor,
+// the part not covered by task bars and such, instead of where the OS puts it. It has no
+// effect on maximized and fullscreen windows.
+func Centered() Option {
+	return func(o *options) {
+		o.centered = true
+	}
+}
+
+// Transparent option makes the framebuffer of the window transparent, so the desktop shows
+// through wherever the alpha of the content is below 1. The framebuffer gets cleared to fully
+// transparent instead of opaque and the parts of the gui that aren't drawn onto stay
+// transparent too. Not all platforms support it.
+func Transparent() Option {
+	return func(o *options) {
+		o.transparent = true
+	}
+}
+
+// VirtualResolution option makes the window keep its drawing area at the given width and
+// height, no matter the size of the window.
+//
+// The drawing area gets scaled to fit the window, keeping its aspect ratio, and centered.
+// The bars left over on the sides are filled with the LetterboxColor. All mouse events are
+// mapped back to the coordinates of the drawing area. The window never produces any further
+// Resize events, since the drawing area never changes its size.
+//
+// The width and the height must be positive, New returns an error otherwise.
+func VirtualResolution(width, height int) Option {
+	return func(o *options) {
+		o.virtual = image.Pt(width, height)
+	}
+}
+
+// RedrawRate option sets how many times per second at most the window puts changes on the
+// screen, the default is 960. After a change, the window waits about that long for more, so
+// a burst of drawing functions ends up in one update. A lower rate saves work when lots of
+// small changes arrive, a higher one gets single changes on the screen sooner. The rate gets
+// rounded up to a whole multiple of the refresh rate of the monitor.
+//
+// With vsync, see Swap, putting an update on the screen waits for the next refresh anyway,
+// so the rate is only an upper bound then. See also SetRedrawRate.
+func RedrawRate(hz int) Option {
+	return func(o *options) {
+		o.redrawRate = hz
+	}
+}
+
+// GUIScale option scales the gui up by the factor onto the framebuffer, e.g. by 2 on a hiDPI
+// display. The drawing area is that much smaller than the framebuffer and the gui code draws
+// in its larger, logical pixels, while the Open GL content renders at the full resolution of
+// the framebuffer. All mouse events are mapped to the coordinates of the drawing area and
+// the Resize events tell its size. Use the GUIFilter option to keep the pixels sharp.
+//
+// A factor of 1 or less leaves the gui unscaled. It has no effect together with the
+// VirtualResolution option, which scales the gui to fit the window.
+func GUIScale(factor float64) Option {
+	return func(o *options) {
+		o.guiScale = factor
+	}
+}
+
+// LetterboxColor option sets the color of the bars around the drawing area when using the
+// VirtualResolution option. The default is black.
+func LetterboxColor(c color.Color) Option {
+	return func(o *options) {
+		o.letterbox = c
+	}
+}
+
+// NoGui option turns off the gui layer of the window, for apps that only draw with Open GL.
+//
+// The window then doesn't allocate the gui image and texture and doesn't composite the gui
+// over the Open GL content, it just swaps the buffers after each function sent to the GL()
+// channel. The Draw() channel becomes a no-op: drawing functions sent to it, to DrawZ or to
+// Update are dropped without being run. Closing the Draw() channel still closes the window.
+func NoGui() Option {
+	return func(o *options) {
+		o.noGui = true
+	}
+}
+
+// Opacity option sets the opacity of the whole window, from 0 (fully transparent) to 1
+// (fully opaque, the default). Not all platforms support it, see SetOpacity.
+func Opacity(a float32) Option {
+	return func(o *options) {
+		o.opacity = a
+	}
+}
+
+// ContextVersion option requests an Open GL context of the given version. The default is
+// 4.2, the lowest supported version is 3.3, New returns an error for a lower one.
+//
+// The window itself only uses Open GL 3.3 functions, through the v3.3-core bindings of
+// go-gl, so it works with any of these versions. Functions sent to GL that use other
+// bindings, e.g. v4.2-core, need to initialize those with their Init function first, see
+// OnGLInit, and must not use functions the requested version lacks.
+func ContextVersion(major, minor int) Option {
+	return func(o *options) {
+		o.glMajor = major
+		o.glMinor = minor
+	}
+}
+
+// CompatibilityProfile option requests an Open GL compatibility profile context instead of
+// a forward compatible core profile one.
+func CompatibilityProfile() Option {
+	return func(o *options) {
+		o.compatProfile = true
+	}
+}
+
+// Samples option turns on multisample anti-aliasing of the Open GL content with n samples
+// per pixel.
+//
+// The gui doesn't need any special handling, it's composited by drawing a quad that covers
+// whole pixels, without any reading back from, or blitting of the framebuffer.
+func Samples(n int) Option {
+	return func(o *options) {
+		o.samples = n
+	}
+}
+
+// ProcessWhenUnfocused option sets whether the window keeps producing frames for the OnFrame
+// callbacks at the full refresh rate while it doesn't have the focus, e.g. for an overlay
+// floating over other windows. By default it saves power by slowing them down to 10 frames
+// per second while another window has the focus.
+//
+// Events, drawing functions and Open GL functions get handled right away either way.
+func ProcessWhenUnfocused(process bool) Option {
+	return func(o *options) {
+		o.whenUnfocused = process
+	}
+}
+
+// SRGB option makes the framebuffer sRGB capable and turns on GL_FRAMEBUFFER_SRGB, so the
+// Open GL content can do its lighting in linear colors and Open GL converts them to sRGB,
+// instead of looking washed out or too dark.
+//
+// The colors of the gui are sRGB already, like all colors of the image/color package. The
+// conversion stays off while compositing the gui, so it looks exactly the same with and
+// without this option.
+func SRGB() Option {
+	return func(o *options) {
+		o.srgb = true
+	}
+}
+
+// DepthBits option sets the number of bits of the depth buffer. The default is 24.
+func DepthBits(n int) Option {
+	return func(o *options) {
+		o.depthBits = n
+	}
+}
+
+// StencilBits option sets the number of bits of the stencil buffer. The default is 8.
+//
+// The gui gets composited with the stencil test disabled and it never touches the stencil
+// buffer, so whatever Open GL functions put in there stays.
+func StencilBits(n int) Option {
+	return func(o *options) {
+		o.stencilBits = n
+	}
+}
+
+// PixelBuffers option makes the gui get uploaded to its texture through a ring of pixel
+// buffer objects. The changed part gets composited straight into the mapped buffer and the
+// texture upload from it runs asynchronously, which saves an allocation and a copy per frame
+// and keeps large updates from stalling the Open GL thread.
+//
+// The buffers get mapped for each upload, persistent mapping would need the buffer storage
+// of Open GL 4.4.
+func PixelBuffers() Option {
+	return func(o *options) {
+		o.pixelBuffers = true
+	}
+}
+
+// Hidden option creates the window without showing it on the screen, until Show. Everything
+// works just like with a visible window, but Open GL leaves the content of a framebuffer that
+// isn't on the screen undefined, so use NewOffscreen to read back the rendering in tests.
+func Hidden() Option {
+	return func(o *options) {
+		o.hidden = true
+	}
+}
+
+// Swap option sets how swapping the buffers waits for the refresh of the monitor. Without
+// it, the default of the driver stays. See also SetSwapMode.
+func Swap(m SwapMode) Option {
+	return func(o *options) {
+		o.swap = &m
+	}
+}
+
+// SingleBuffer option creates the window with a single buffer instead of the usual two. The
+// window then renders right onto the screen and doesn't swap the buffers, it only flushes
+// the Open GL commands, which saves the latency of waiting for the swap.
+//
+// The downside is that the screen shows the rendering as it's happening, so it can flicker
+// and tear, and the Swap option and SetSwapMode have no effect. Not all platforms support
+// single buffered windows, New returns an error there.
+func SingleBuffer() Option {
+	return func(o *options) {
+		o.singleBuffer = true
+	}
+}
+
+// CoalesceMouseMove option sets whether mouse movements get merged while the events are
+// waiting to be received. Then, when the app falls behind, it gets the latest position
+// instead of a flood of outdated ones, the MoMoveRel events add up. Clicks, keys and all
+// the other events never get merged or dropped. It's on by default.
+func CoalesceMouseMove(coalesce bool) Option {
+	return func(o *options) {
+		o.coalesce = coalesce
+	}
+}
+
+// Fullscreen option makes the window cover the whole monitor with the given index, where 0
+// is the primary monitor, switching it to the given video mode, see VideoModes. The zero
+// VideoMode keeps the current mode of the monitor. The window then has the size of the
+// video mode, whatever the Size option says.
+func Fullscreen(monitorIndex int, mode VideoMode) Option {
+	return func(o *options) {
+		o.fullscreen = true
+		o.monitorIndex = monitorIndex
+		o.videoMode = mode
+	}
+}
+
+// GUIFilter option sets how the gui gets filtered when it's scaled onto the screen, which
+// happens with a virtual resolution or a GUIScale. Linear filtering, the default, blends
+// neighboring pixels, which looks smooth but blurry. Nearest filtering keeps every pixel
+// sharp, which suits pixel art and crisp text.
+func GUIFilter(nearest bool) Option {
+	return func(o *options) {
+		o.nearest = nearest
+	}
+}
+
+// FullUpload option sets the fraction of the pixels of the gui above which an update
+// uploads the whole gui to its texture in one go, instead of each changed part on its own.
+// One upload of the whole gui saves the calls and the copies of the parts, so it pays off
+// once a good part of the gui changed. The default is 0.3, 1 turns it off and 0 always
+// uploads the whole gui.
+//
+// The default comes from BenchmarkUpload, which measures the copies on the CPU for a
+// 1920x1080 gui with the changes spread over 4 parts: with Go 1.27 on linux/amd64 and an
+// Intel Xeon, the parts cost as much as the whole gui at about a third of it, 0.3 took
+// 340µs and the whole gui 395µs. The transfer to the GPU, which it doesn't measure, depends
+// on the driver, so for a particular app it's best tuned by comparing the FrameStats of
+// typical updates.
+func FullUpload(fraction float64) Option {
+	return func(o *options) {
+		o.fullUpload = fraction
+	}
+}
+
 // New creates a new window with all the supplied options.
 //
 // The default title is empty and the default size is 640x480.
 func New(opts ...Option) (*Win, error) {
 	o := options{
-		title:      "",
-		width:      640,
-		height:     480,
-		resizable:  false,
-		borderless: false,
-		maximized:  false,
+		title:       "",
+		width:       640,
+		height:      480,
+		resizable:   false,
+		borderless:  false,
+		maximized:   false,
+		letterbox:   color.Black,
+		opacity:     1,
+		glMajor:     4,
+		glMinor:     2,
+		depthBits:   24,
+		stencilBits: 8,
+		coalesce:    true,
+		fullUpload:  0.3,
 	}
 	for _, opt := range opts {
 		opt(&o)
 	}
+	if o.glMajor < 3 || o.glMajor == 3 && o.glMinor < 3 {
+		return nil, fmt.Errorf("open gl %d.%d not supported, need at least 3.3", o.glMajor, o.glMinor)
+	}
+	if o.virtual != (image.Point{}) && (o.virtual.X <= 0 || o.virtual.Y <= 0) {
+		return nil, fmt.Errorf("invalid virtual resolution %dx%d", o.virtual.X, o.virtual.Y)
+	}
 
-	eventsOut, eventsIn := gui.MakeEventsChan()
+	var (
+		eventsOut <-chan gui.Event
+		eventsIn  chan<- gui.Event
+	)
+	if o.coalesce {
+		eventsOut, eventsIn = makeCoalescingEventsChan()
+	} else {
+		eventsOut, eventsIn = gui.MakeEventsChan()
+	}
 
 	w := &Win{
-		eventsOut: eventsOut,
-		eventsIn:  eventsIn,
-		draw:      make(chan func(draw.Image) image.Rectangle),
-		drawGL:    make(chan func()),
-		newSize:   make(chan image.Rectangle),
-		finish:    make(chan struct{}),
+		eventsOut:      eventsOut,
+		eventsIn:       eventsIn,
+		draw:           make(chan func(draw.Image) image.Rectangle),
+		drawGL:         make(chan func()),
+		drawZ:          make(chan zDraw),
+		glCalls:        make(chan func()),
+		layerDraws:     make(chan layerDraw),
+		redraw:         make(chan struct{}, 1),
+		newSize:        make(chan image.Rectangle, 1),
+		finish:         make(chan struct{}),
+		confirmClose:   make(chan struct{}),
+		requested:      image.Pt(o.width, o.height),
+		gamepads:       make(map[glfw.Joystick]GamepadState),
+		cursors:        make(map[glfw.StandardCursor]*glfw.Cursor),
+		registered:     make(map[string]func(draw.Image) image.Rectangle),
+		virtual:        o.virtual,
+		letterboxColor: o.letterbox,
+		noGui:          o.noGui,
+		glMajor:        o.glMajor,
+		glMinor:        o.glMinor,
+		compatProfile:  o.compatProfile,
+		glslVersion:    glslVersion(o.glMajor, o.glMinor),
+		samples:        o.samples,
+		srgb:           o.srgb,
+		whenUnfocused:  o.whenUnfocused,
+		transparent:    o.transparent,
+		pixelBuffers:   o.pixelBuffers,
+		swap:           o.swap,
+		singleBuffer:   o.singleBuffer || o.offscreen, // a framebuffer object has one buffer
+		offscreen:      o.offscreen,
+		guiFilter:      gl.LINEAR,
+		fullUpload:     o.fullUpload,
+	}
+	if o.guiScale > 1 {
+		w.guiScale = o.guiScale
+	}
+	if o.nearest {
+		w.guiFilter = gl.NEAREST
 	}
 
 	var err error
@@ -117,29 +475,95 @@ func New(opts ...Option) (*Win, error) {
 		return nil, err
 	}
 
-	bounds := image.Rect(0, 0, o.width*w.ratio, o.height*w.ratio)
-	w.img = image.NewRGBA(bounds)
+	bounds := image.Rectangle{Max: w.guiSize(image.Pt(o.width*w.ratio, o.height*w.ratio))}
+	if w.noGui {
+		w.img = &image.RGBA{Rect: bounds} // only keeps track of the size
+	} else {
+		w.img = image.NewRGBA(bounds)
+	}
+
+	mainthread.Call(func() {
+		rate := refreshRate(w.w)
+		w.refreshRate = rate
+		w.flushDelay = flushDelay(rate, o.redrawRate)
+		w.frameInterval = frameInterval(rate)
+	})
 
+	setupErr := make(chan error)
 	go func() {
 		runtime.LockOSThread()
-		w.openGLThread()
+		w.openGLThread(setupErr)
 	}()
+	if err := <-setupErr; err != nil {
+		mainthread.Call(w.w.Destroy)
+		return nil, err
+	}
 
 	mainthread.CallNonBlock(w.eventThread)
 
 	return w, nil
 }
 
+// NewOffscreen creates a window with a drawing area of the given size that renders into a
+// framebuffer object instead of onto the screen, e.g. for tests. Read back the result with
+// Screenshot or PixelAt, after Flush. Other than that, it's the same as New with the Size and
+// Hidden options, followed by opts.
+//
+// The framebuffer object has a color and a depth and stencil attachment of the size of the
+// drawing area. It takes the place of the default framebuffer: it gets bound before the
+// Open GL functions run, like one set with SetRenderTarget, and it isn't double buffered.
+//
+// It still needs a window of the OS for the Open GL context, just never shown, so it needs a
+// display to connect to, e.g. a virtual one like Xvfb on a CI machine.
+func NewOffscreen(width, height int, opts ...Option) (*Win, error) {
+	offscreen := func(o *options) {
+		o.offscreen = true
+	}
+	return New(append([]Option{Size(width, height), Hidden(), offscreen}, opts...)...)
+}
+
+// NewWithContext is like New, but ties the window to ctx: once ctx is done, the window gets
+// closed, just like with ConfirmClose, right after a WiClose event with CloseReasonContext.
+func NewWithContext(ctx context.Context, opts ...Option) (*Win, error) {
+	w, err := New(opts...)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		select {
+		case <-ctx.Done():
+			w.callMain(func() {
+				w.eventsIn <- WiClose{CloseReasonContext}
+			})
+			w.ConfirmClose()
+		case <-w.finish:
+		}
+	}()
+	return w, nil
+}
+
+// contextHints sets the window hints for the Open GL context.
+func contextHints(major, minor int, compat bool) {
+	glfw.WindowHint(glfw.ContextVersionMajor, major)
+	glfw.WindowHint(glfw.ContextVersionMinor, minor)
+	if compat {
+		glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCompatProfile)
+		glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.False)
+	} else {
+		glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
+		glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
+	}
+}
+
 func makeGLFWWin(o *options) (*glfw.Window, error) {
 	err := glfw.Init()
 	if err != nil {
 		return nil, err
 	}
-	//glfw.WindowHint(glfw.DoubleBuffer, glfw.False)
-	glfw.WindowHint(glfw.ContextVersionMajor, 4)
-	glfw.WindowHint(glfw.ContextVersionMinor, 2)
-	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
-	glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
+	contextHints(o.glMajor, o.glMinor, o.compatProfile)
+	if o.singleBuffer {
+		glfw.WindowHint(glfw.DoubleBuffer, glfw.False)
+	}
 	if o.resizable {
 		glfw.WindowHint(glfw.Resizable, glfw.True)
 	} else {
@@ -148,22 +572,88 @@ func makeGLFWWin(o *options) (*glfw.Window, error) {
 	if o.borderless {
 		glfw.WindowHint(glfw.Decorated, glfw.False)
 	}
+	if o.samples > 0 {
+		glfw.WindowHint(glfw.Samples, o.samples)
+	}
+	if o.srgb {
+		glfw.WindowHint(glfw.SRGBCapable, glfw.True)
+	}
+	glfw.WindowHint(glfw.DepthBits, o.depthBits)
+	glfw.WindowHint(glfw.StencilBits, o.stencilBits)
 	if o.maximized {
 		glfw.WindowHint(glfw.Maximized, glfw.True)
 	}
-	w, err := glfw.CreateWindow(o.width, o.height, o.title, nil, nil)
+	if o.floating {
+		glfw.WindowHint(glfw.Floating, glfw.True)
+	}
+	// a centered window shows up only once it's in place
+	center := o.centered && !o.maximized && !o.fullscreen
+	if o.hidden || center {
+		glfw.WindowHint(glfw.Visible, glfw.False)
+	} else {
+		glfw.WindowHint(glfw.Visible, glfw.True)
+	}
+	if o.transparent {
+		glfw.WindowHint(glfw.TransparentFramebuffer, glfw.True)
+	}
+	width, height := o.width, o.height
+	if o.fullscreen {
+		if o.monitor == nil {
+			if err := fullscreenMonitor(o); err != nil {
+				return nil, err
+			}
+		}
+		glfw.WindowHint(glfw.RedBits, o.videoMode.RedBits)
+		glfw.WindowHint(glfw.GreenBits, o.videoMode.GreenBits)
+		glfw.WindowHint(glfw.BlueBits, o.videoMode.BlueBits)
+		glfw.WindowHint(glfw.RefreshRate, o.videoMode.RefreshRate)
+		width, height = o.videoMode.Width, o.videoMode.Height
+	}
+	w, err := glfw.CreateWindow(width, height, o.title, o.monitor, nil)
 	if err != nil {
 		return nil, err
 	}
+	if o.opacity < 1 {
+		w.SetOpacity(o.opacity)
+	}
+	if center {
+		centerWindow(w)
+		if !o.hidden {
+			w.Show()
+		}
+	}
 	if o.maximized {
 		o.width, o.height = w.GetFramebufferSize() // set o.width and o.height to the window size due to the window being maximized
 	}
 	return w, nil
 }
 
+// centerWindow moves the window, including its decorations, to the middle of the work area
+// of the primary monitor. The work area and the size of the window are both in screen
+// coordinates, so it's centered the same on hiDPI displays.
+func centerWindow(w *glfw.Window) {
+	m := glfw.GetPrimaryMonitor()
+	if m == nil {
+		return
+	}
+	x, y, width, height := m.GetWorkarea()
+	wid, hei := w.GetSize()
+	left, top, right, bottom := w.GetFrameSize()
+	w.SetPos(
+		x+(width-(left+wid+right))/2+left,
+		y+(height-(top+hei+bottom))/2+top,
+	)
+}
+
 // Win is an Env that handles an actual graphical window.
 //
-// It receives its events from the OS and it draws to the surface of the window.
+// It receives its events from the OS and it draws to the surface of the window. Code that
+// should work with any Env, e.g. widgets that get tested with a fake one, takes a gui.Env,
+// which Win implements.
+//
+// When the drawing area gets resized, the window keeps its old content, puts the whole new
+// drawing area on the screen, with the newly exposed parts transparent, and produces a
+// gui.Resize event. Whatever got exposed needs to be repainted in response to the event.
 //
 // Warning: only one window can be open at a time
 type Win struct {
@@ -171,29 +661,528 @@ type Win struct {
 	eventsIn  chan<- gui.Event
 	draw      chan func(draw.Image) image.Rectangle
 	drawGL    chan func()
-
-	newSize chan image.Rectangle
-	finish  chan struct{}
-
-	w     *glfw.Window
-	img   *image.RGBA
-	ratio int
+	drawZ     chan zDraw
+
+	glCalls    chan func()
+	layerDraws chan layerDraw
+	redraw     chan struct{}
+	newSize    chan image.Rectangle
+	finish     chan struct{}
+
+	confirmClose chan struct{}
+
+	requested     image.Point
+	gamepads      map[glfw.Joystick]GamepadState       // last polled, only used on the main thread
+	cursors       map[glfw.StandardCursor]*glfw.Cursor // only used on the main thread
+	captured      bool                                 // see SetCursorCapture, only used on the main thread
+	lastCursor    [2]float64                           // only used on the main thread
+	drag          *windowDrag                          // see StartDrag, only used on the main thread
+	shortcuts     []shortcutHandler                    // see AddShortcut, only used on the main thread
+	shortcutCalls chan<- gui.Event                     // queue of shortcutCall, only used on the main thread
+
+	w *glfw.Window
+
+	// The gui image is only used on the Open GL thread, which also runs the drawing
+	// functions, right before uploading the parts they changed. So the upload never sees a
+	// half drawn image, or one that resize is replacing, without any locking. The event
+	// thread only reads its initial size, before anything can resize it.
+	img    *image.RGBA
+	ratio  int
+	queue  []zDraw
+	layers []*Layer   // sorted by z
+	dirty  dirtyRects // parts of img changed since they were last put on the screen
+
+	onResize []func(width, height int) // only used on the Open GL thread
+	frames   frameTimer
+
+	flushDelay  time.Duration // how long to wait for more work before updating the screen
+	refreshRate int           // of the monitor when the window got created, 0 if unknown
+
+	onFrame       []func(dt time.Duration) // only used on the Open GL thread
+	lastFrame     time.Time
+	frameInterval time.Duration
+	whenUnfocused bool        // see ProcessWhenUnfocused
+	unfocused     atomic.Bool // set on the main thread
+
+	registered map[string]func(draw.Image) image.Rectangle // see RegisterDraw
+
+	// virtual resolution, the zero point if not used
+	virtual        image.Point
+	guiScale       float64         // see GUIScale, 0 if not used
+	view           image.Rectangle // where the gui goes in the framebuffer
+	letterboxColor color.Color
+
+	noGui bool
+
+	glMajor, glMinor int
+	compatProfile    bool
+	glslVersion      string // version directive of the internal shaders
+	samples          int
+	srgb             bool
+	transparent      bool
+	pixelBuffers     bool
+	fullUpload       float64   // see FullUpload
+	swap             *SwapMode // nil keeps the default of the driver
+	singleBuffer     bool
+	offscreen        bool
 
 	// open gl stuff
-	guiTexture uint32
-	guiShader  uint32
-	quadVao    uint32
+	guiTexture      uint32
+	texSize         image.Point // allocated size of guiTexture, may be bigger than the gui
+	texScaleUniform int32
+	texMaxUniform   int32
+	guiFilter       int32 // gl.LINEAR or gl.NEAREST
+	guiShader       uint32
+	quadVao         uint32
+	quadVbo         uint32
+	target          uint32                   // framebuffer object to render into, see SetRenderTarget
+	offscreenBuf    *offscreenBuffer         // nil unless NewOffscreen
+	pbos            [pixelBufferCount]uint32 // ring of pixel buffers, see PixelBuffers
+	pboNext         int
 }
 
 // Events returns the events channel of the window.
 func (w *Win) Events() <-chan gui.Event { return w.eventsOut }
 
+// NextEvent waits for the next event of the window and returns it. It returns false once the
+// window has closed and all its events have been received.
+//
+// It's the same as receiving from the Events() channel, for apps that handle events in a
+// plain loop instead of a select.
+func (w *Win) NextEvent() (gui.Event, bool) {
+	e, ok := <-w.eventsOut
+	return e, ok
+}
+
+// InjectEvent sends the event e to the Events() channel as if it came from the OS, e.g. to
+// script user input in a test, together with a Hidden window. The event goes through the
+// queue like all the others, after the ones already waiting there.
+//
+// Only the event gets sent, the state of the window stays as it was. KeyPressed, CursorPos
+// and such don't see injected input and shortcuts don't fire on it.
+//
+// It's safe to call from any goroutine. It does nothing if the window is closed.
+func (w *Win) InjectEvent(e gui.Event) {
+	// events only get sent from the main thread, which also closes the channel
+	w.callMain(func() {
+		w.eventsIn <- e
+	})
+}
+
 // Draw returns the draw channel of the window.
+//
+// The drawing functions run on the Open GL thread, one after another and never at the same
+// time as the gui gets uploaded to the screen, so they don't need any locking of the image
+// and an update never shows a function's drawing only in part.
+//
+// The drawing area is an *image.RGBA and it gets composited over the Open GL content using
+// its alpha channel. Like all colors of the image/color package, its pixels are alpha-
+// premultiplied. Semi-transparent colors with straight alpha must be given as color.NRGBA,
+// a color.RGBA with a component larger than its alpha is invalid and composites too bright.
 func (w *Win) Draw() chan<- func(draw.Image) image.Rectangle { return w.draw }
 
 // GL returns the Open GL draw channel of the window.
 func (w *Win) GL() chan<- func() { return w.drawGL }
 
+// ContentScale returns the pixel density of the window, that is the number of pixels of the
+// drawing area per screen coordinate. It's 1 on a regular display and usually 2 on a hiDPI
+// display. Use it to size text, line widths and such.
+//
+// The scale is fixed when the window gets created, so it's safe to call ContentScale from
+// any goroutine, including from functions sent to the GL() channel.
+func (w *Win) ContentScale() float64 { return float64(w.ratio) }
+
+// SetOpacity sets the opacity of the whole window, from 0 (fully transparent) to 1 (fully
+// opaque). Values out of that range get clamped.
+//
+// It returns an error if the platform doesn't support window opacity, or if the window is
+// closed.
+func (w *Win) SetOpacity(a float32) error {
+	if a < 0 {
+		a = 0
+	}
+	if a > 1 {
+		a = 1
+	}
+	var got float32
+	if !w.callMain(func() {
+		w.w.SetOpacity(a)
+		got = w.w.GetOpacity()
+	}) {
+		return errClosed
+	}
+	// the platform may store the opacity with 8 bits only
+	if got-a > 1.0/255 || a-got > 1.0/255 {
+		return errors.New("window opacity not supported")
+	}
+	return nil
+}
+
+// SetClearColor sets the Open GL clear color, the one gl.Clear fills the color buffer with.
+// It's what shows behind the transparent parts of the gui where no Open GL function drew
+// anything. The default is black, or fully transparent with the Transparent option.
+//
+// It does nothing if the window is closed.
+func (w *Win) SetClearColor(c color.Color) {
+	w.glCall(func() { setClearColor(c) })
+}
+
+// SetFloating sets whether the window stays on top of other windows. It doesn't change
+// whether the window has borders. It does nothing if the window is closed.
+func (w *Win) SetFloating(floating bool) {
+	w.callMain(func() {
+		if floating {
+			w.w.SetAttrib(glfw.Floating, glfw.True)
+		} else {
+			w.w.SetAttrib(glfw.Floating, glfw.False)
+		}
+	})
+}
+
+// ConfirmClose closes the window, just like closing the Draw() channel.
+//
+// Pressing the close button of the window never closes it by itself, it only produces a
+// WiClose event. This way the app gets to decide, e.g. ask about unsaved changes first, and
+// call ConfirmClose or just keep going. ConfirmClose does nothing if the window is closed.
+//
+// Functions sent to the Draw() and GL() channels afterwards get dropped, so goroutines that
+// are still drawing don't block. Close the Draw() channel when done with the window anyway.
+func (w *Win) ConfirmClose() {
+	select {
+	case w.confirmClose <- struct{}{}:
+	case <-w.finish:
+	}
+}
+
+// RequestClose asks the app to close the window, like pressing its close button, but with a
+// WiClose event with CloseReasonProgrammatic. That way closing the window from the code,
+// e.g. from a menu, goes through the same handling and the app can still skip questions
+// meant for the user. It does nothing if the window is closed.
+func (w *Win) RequestClose() {
+	w.callMain(func() {
+		w.eventsIn <- WiClose{CloseReasonProgrammatic}
+	})
+}
+
+// RequestAttention asks for the attention of the user, e.g. by flashing the entry of the
+// window in the taskbar, if it's not focused. It does nothing if the window is closed.
+func (w *Win) RequestAttention() {
+	w.callMain(w.w.RequestAttention)
+}
+
+// Show shows the window if it's hidden, e.g. created with the Hidden option. It does
+// nothing if the window is closed.
+func (w *Win) Show() {
+	w.callMain(w.w.Show)
+}
+
+// Hide hides the window, it keeps working, just without showing up on the screen. It does
+// nothing if the window is closed.
+func (w *Win) Hide() {
+	w.callMain(w.w.Hide)
+}
+
+// Iconify minimizes (iconifies) the window, which produces a WiMinimize event. It does
+// nothing if the window is closed.
+func (w *Win) Iconify() {
+	w.callMain(w.w.Iconify)
+}
+
+// Restore restores the window from being minimized or maximized, which produces a WiRestore
+// event. It does nothing if the window is closed.
+func (w *Win) Restore() {
+	w.callMain(w.w.Restore)
+}
+
+// Maximize maximizes the window, which produces a WiMaximize event. It does nothing if the
+// window is closed.
+func (w *Win) Maximize() {
+	w.callMain(w.w.Maximize)
+}
+
+// SetResizable sets whether the user can resize the window, e.g. to lock the size while a
+// dialog is open. It overrides the Resizable option. It does nothing if the window is
+// closed.
+func (w *Win) SetResizable(resizable bool) {
+	w.callMain(func() {
+		value := glfw.False
+		if resizable {
+			value = glfw.True
+		}
+		w.w.SetAttrib(glfw.Resizable, value)
+	})
+}
+
+// Resizable tells whether the user can resize the window, see SetResizable. It returns
+// false if the window is closed.
+func (w *Win) Resizable() bool {
+	var resizable bool
+	w.callMain(func() {
+		resizable = w.w.GetAttrib(glfw.Resizable) == glfw.True
+	})
+	return resizable
+}
+
+// GLFWWindow returns the underlying glfw window, for the things the window doesn't wrap,
+// like querying keys with GetKey.
+//
+// Almost all glfw functions must only be called on the main thread, so wrap the calls in
+// mainthread.Call. Don't destroy the window or replace its callbacks, the events of the
+// window stop working otherwise, and don't use it anymore once the window is closed.
+func (w *Win) GLFWWindow() *glfw.Window {
+	return w.w
+}
+
+// RequestedSize returns the size of the window requested with the Size option, or the
+// default size if none was given.
+func (w *Win) RequestedSize() image.Point { return w.requested }
+
+// ActualSize returns the current size of the drawing area of the window.
+//
+// Both sizes are in pixels of the drawing area. On a hiDPI display the window gets created
+// smaller by the pixel ratio of the display, so that its drawing area ends up with the
+// requested size. The sizes still differ if the window was created maximized, if the OS
+// clamped the size, or if the window got resized since. The zero size is returned after the
+// window got closed.
+func (w *Win) ActualSize() image.Point {
+	reply := make(chan image.Point, 1)
+	if !w.glCall(func() { reply <- w.img.Bounds().Size() }) {
+		return image.Point{}
+	}
+	return <-reply
+}
+
+// OnResize registers f to be called on the Open GL thread whenever the framebuffer of the
+// window changes size, after the window is done handling the new size. It gets the size of
+// the framebuffer in pixels, so it's the place to update the viewport and the projection of
+// the Open GL functions.
+//
+// f gets called once right away with the current size too. OnResize does nothing if the
+// window is closed.
+func (w *Win) OnResize(f func(width, height int)) {
+	w.glCall(func() {
+		w.onResize = append(w.onResize, f)
+		f(w.w.GetFramebufferSize())
+	})
+}
+
+// OnGLInit runs f on the Open GL thread, before anything sent to the window afterwards, and
+// returns once it's done. It's the place to set up the Open GL state and allocate the
+// resources for the Open GL content, e.g. before registering an OnFrame callback to render
+// it. Compositing the gui leaves the state it finds untouched, so whatever f sets up stays.
+//
+// The render target is the default framebuffer, or the one set with SetRenderTarget. It
+// does nothing if the window is closed.
+func (w *Win) OnGLInit(f func()) {
+	done := make(chan struct{})
+	ok := w.glCall(func() {
+		w.bindTarget()
+		f()
+		close(done)
+	})
+	if ok {
+		<-done
+	}
+}
+
+// OnFrame registers f to be called on the Open GL thread for every frame, for animating the
+// Open GL content. It gets the time since the previous frame, which is 0 for the first one.
+//
+// As long as there are OnFrame callbacks, the window keeps putting new frames on the screen
+// at the refresh rate of the monitor, even if nothing else changes. The callbacks render the
+// Open GL content, then the whole gui gets composited over it. OnFrame does nothing if the
+// window is closed.
+func (w *Win) OnFrame(f func(dt time.Duration)) {
+	w.glCall(func() {
+		w.onFrame = append(w.onFrame, f)
+	})
+}
+
+// callOnFrame calls the OnFrame callbacks, if there are any. It reports whether it did.
+func (w *Win) callOnFrame() bool {
+	if len(w.onFrame) == 0 {
+		return false
+	}
+	now := time.Now()
+	var dt time.Duration
+	if !w.lastFrame.IsZero() {
+		dt = now.Sub(w.lastFrame)
+	}
+	w.lastFrame = now
+	w.bindTarget()
+	for _, f := range w.onFrame {
+		f(dt)
+	}
+	return true
+}
+
+// glCall sends f to be run on the OpenGL thread, without waiting for it to run. It returns
+// false if the window was closed and f will never run.
+func (w *Win) glCall(f func()) bool {
+	select {
+	case w.glCalls <- f:
+		return true
+	case <-w.finish:
+		return false
+	}
+}
+
+// Update runs the drawing functions draws in order, then the Open GL function render, and
+// then puts the result on the screen with a single composite and swap. It returns once
+// that's done.
+//
+// Functions sent to the Draw() and the GL() channels close together may be run in any order
+// relative to each other. Update guarantees this precise order on the Open GL thread:
+//
+//  1. Everything the window received before Update is handled, including all the queued
+//     drawing functions, which get applied.
+//  2. The draws are applied one by one, in the order of the slice, ignoring z hints.
+//  3. The render function is called, unless it's nil.
+//  4. The changed part of the gui is composited over the Open GL content and the buffers
+//     get swapped.
+//
+// Nothing else runs between these steps. Update does nothing if the window is closed.
+func (w *Win) Update(draws []func(draw.Image) image.Rectangle, render func()) {
+	done := make(chan struct{})
+	ok := w.glCall(func() {
+		w.applyDraws()
+		for _, d := range draws {
+			if w.noGui {
+				break
+			}
+			w.dirty.add(d(w.img))
+		}
+		if render != nil {
+			w.bindTarget()
+			render()
+		}
+		w.present()
+		w.dirty = w.dirty[:0]
+		close(done)
+	})
+	if ok {
+		<-done
+	}
+}
+
+// RequestRedraw makes the window upload and composite the whole gui with the next update,
+// not just the parts changed by drawing functions. Use it after a change that affects the
+// whole gui, like switching themes.
+//
+// RequestRedraw doesn't block. Requests coalesce with each other and with pending changes,
+// requesting many redraws before the next update results in a single one.
+func (w *Win) RequestRedraw() {
+	select {
+	case w.redraw <- struct{}{}:
+	default: // a redraw is pending already
+	}
+}
+
+// zDraw is a drawing function along with its z hint.
+type zDraw struct {
+	z int
+	d func(draw.Image) image.Rectangle
+}
+
+// DrawZ sends the drawing function d to the window, just like the Draw() channel, but with
+// a z hint.
+//
+// Drawing functions are not applied right away, they are collected and applied all at once
+// in ascending z order, just before the changes get uploaded to the screen. Functions with
+// an equal z are applied in the order they were sent. Functions sent to the Draw() channel
+// have z 0.
+//
+// Note that this only reorders drawing functions within a single batched update. A function
+// that arrives after an update got uploaded draws over everything before it, no matter the z.
+func (w *Win) DrawZ(z int, d func(draw.Image) image.Rectangle) {
+	select {
+	case w.drawZ <- zDraw{z, d}:
+	case <-w.finish:
+	}
+}
+
+// TryDraw sends the drawing function d to the window, just like the Draw() channel, but
+// only if the window is ready to take it right now. Otherwise, or if the window is closed,
+// it drops d and returns false instead of blocking. It's meant for frames that are fine to
+// skip, e.g. of a continuously updating plot.
+func (w *Win) TryDraw(d func(draw.Image) image.Rectangle) bool {
+	select {
+	case w.draw <- d:
+		return true
+	default:
+		return false
+	}
+}
+
+// RegisterDraw keeps the drawing function d around under the given id, for widgets that
+// repaint on their own schedule. d gets applied right away and then again each time the id
+// gets passed to Invalidate, just like sending it to the Draw() channel. Registering another
+// function under the same id replaces the old one, registering nil removes it.
+//
+// It does nothing if the window is closed.
+func (w *Win) RegisterDraw(id string, d func(draw.Image) image.Rectangle) {
+	w.glCall(func() {
+		if d == nil {
+			delete(w.registered, id)
+			return
+		}
+		w.registered[id] = d
+		if !w.noGui {
+			w.queue = append(w.queue, zDraw{0, d})
+		}
+	})
+}
+
+// Invalidate applies the drawing function registered under id with RegisterDraw again. It
+// does nothing if there is none or the window is closed.
+func (w *Win) Invalidate(id string) {
+	w.glCall(func() {
+		d, ok := w.registered[id]
+		if ok && !w.noGui {
+			w.queue = append(w.queue, zDraw{0, d})
+		}
+	})
+}
+
+// DrawBatch sends all the drawing functions to the window at once, as a single item. They
+// get applied one after another in the given order, with z 0 like the functions sent to the
+// Draw() channel, and always end up on the screen together in one update.
+func (w *Win) DrawBatch(funcs ...func(draw.Image) image.Rectangle) {
+	batch := func(img draw.Image) image.Rectangle {
+		for _, f := range funcs {
+			// runs on the Open GL thread, keep the changed parts apart
+			w.dirty.add(f(img))
+		}
+		return image.ZR
+	}
+	w.DrawZ(0, batch)
+}
+
+// SetRedrawRate changes how many times per second at most the window puts changes on the
+// screen, see the RedrawRate option. A rate of 0 or less goes back to the default. It does
+// nothing if the window is closed.
+func (w *Win) SetRedrawRate(hz int) {
+	w.glCall(func() {
+		w.flushDelay = flushDelay(w.refreshRate, hz)
+	})
+}
+
+// GLBatch sends all the Open GL functions to the window at once, as a single item. They run
+// one after another in the given order, with nothing in between, and the gui gets composited
+// over their result and put on the screen once afterwards, not after each of them like with
+// the GL() channel. It does nothing if the window is closed.
+func (w *Win) GLBatch(funcs ...func()) {
+	batch := func() {
+		for _, f := range funcs {
+			f()
+		}
+	}
+	select {
+	case w.drawGL <- batch:
+	case <-w.finish:
+	}
+}
+
 var buttons = map[glfw.MouseButton]Button{
 	glfw.MouseButtonLeft:   ButtonLeft,
 	glfw.MouseButtonRight:  ButtonRight,
@@ -223,159 +1212,483 @@ var keys = map[glfw.Key]Key{
 	glfw.KeyRightAlt:     KeyAlt,
 }
 
+// pressure returns how hard a pen is pressed on a tablet right now, from 0 to 1. GLFW
+// doesn't report it, so it's the pressure of a mouse, 1, until a platform specific backend
+// replaces it.
+var pressure = func(*glfw.Window) float32 { return 1 }
+
+// modifiers converts the modifier keys from glfw.
+func modifiers(mods glfw.ModifierKey) Modifier {
+	var m Modifier
+	if mods&glfw.ModShift != 0 {
+		m |= ModShift
+	}
+	if mods&glfw.ModControl != 0 {
+		m |= ModCtrl
+	}
+	if mods&glfw.ModAlt != 0 {
+		m |= ModAlt
+	}
+	if mods&glfw.ModSuper != 0 {
+		m |= ModSuper
+	}
+	return m
+}
+
 func (w *Win) eventThread() {
 	var moX, moY int
+	var fb image.Point
+	pressed := make(map[int]time.Time) // when the keys held down got pressed, by scancode
+	fb.X, fb.Y = w.w.GetFramebufferSize()
+
+	// cursor returns the mouse position in the coordinates of the drawing area
+	cursor := func() image.Point {
+		view, size := w.guiView(fb)
+		return toVirtual(image.Pt(moX*w.ratio, moY*w.ratio), view, size)
+	}
 
 	w.w.SetCursorPosCallback(func(_ *glfw.Window, x, y float64) {
+		now := Stamp{time.Now()}
 		moX, moY = int(x), int(y)
-		w.eventsIn <- MoMove{image.Pt(moX*w.ratio, moY*w.ratio)}
+		w.eventsIn <- MoMove{cursor(), now}
+		view, size := w.guiView(fb)
+		fx, fy := toVirtualF(x*float64(w.ratio), y*float64(w.ratio), view, size)
+		w.eventsIn <- MoMoveF{fx, fy, now}
+		if w.captured {
+			ratio := float64(w.ratio)
+			w.eventsIn <- MoMoveRel{(x - w.lastCursor[0]) * ratio, (y - w.lastCursor[1]) * ratio, now}
+		}
+		w.lastCursor = [2]float64{x, y}
+		if w.drag != nil {
+			w.updateDrag()
+		}
 	})
 
 	w.w.SetMouseButtonCallback(func(_ *glfw.Window, button glfw.MouseButton, action glfw.Action, mod glfw.ModifierKey) {
+		now := Stamp{time.Now()}
 		b, ok := buttons[button]
 		if !ok {
 			return
 		}
 		switch action {
 		case glfw.Press:
-			w.eventsIn <- MoDown{image.Pt(moX*w.ratio, moY*w.ratio), b}
+			w.eventsIn <- MoDown{cursor(), b, pressure(w.w), now}
 		case glfw.Release:
-			w.eventsIn <- MoUp{image.Pt(moX*w.ratio, moY*w.ratio), b}
+			w.drag = nil
+			w.eventsIn <- MoUp{cursor(), b, now}
 		}
 	})
 
 	w.w.SetScrollCallback(func(_ *glfw.Window, xoff, yoff float64) {
-		w.eventsIn <- MoScroll{image.Pt(int(xoff), int(yoff))}
+		now := Stamp{time.Now()}
+		w.eventsIn <- MoScroll{image.Pt(int(xoff), int(yoff)), cursor(), now}
+		w.eventsIn <- MoScrollF{xoff, yoff, now}
 	})
 
-	w.w.SetCharCallback(func(_ *glfw.Window, r rune) {
-		w.eventsIn <- KbType{r}
+	w.w.SetCharModsCallback(func(_ *glfw.Window, r rune, mods glfw.ModifierKey) {
+		w.eventsIn <- KbType{r, modifiers(mods), Stamp{time.Now()}}
 	})
 
-	w.w.SetKeyCallback(func(_ *glfw.Window, key glfw.Key, _ int, action glfw.Action, _ glfw.ModifierKey) {
+	w.w.SetKeyCallback(func(_ *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+		now := time.Now()
 		k, ok := keys[key]
 		if !ok {
-			return
+			k = KeyUnknown
 		}
+		w.callShortcuts(k, key, scancode, action, modifiers(mods))
 		switch action {
 		case glfw.Press:
-			w.eventsIn <- KbDown{k}
+			pressed[scancode] = now
+			w.eventsIn <- KbDown{k, scancode, modifiers(mods), Stamp{now}}
 		case glfw.Release:
-			w.eventsIn <- KbUp{k}
+			delete(pressed, scancode)
+			w.eventsIn <- KbUp{k, scancode, modifiers(mods), Stamp{now}}
 		case glfw.Repeat:
-			w.eventsIn <- KbRepeat{k}
+			if _, ok := pressed[scancode]; !ok {
+				// got pressed while the window wasn't focused
+				pressed[scancode] = now
+			}
+			w.eventsIn <- KbRepeat{k, scancode, modifiers(mods), now.Sub(pressed[scancode]), Stamp{now}}
 		}
 	})
 
 	w.w.SetFramebufferSizeCallback(func(_ *glfw.Window, width, height int) {
+		if width == 0 || height == 0 {
+			// minimized, e.g. on Windows, the gui keeps its size until the window is restored
+			return
+		}
 		r := image.Rect(0, 0, width, height)
-		w.newSize <- r
-		w.eventsIn <- gui.Resize{Rectangle: r}
+		if r.Size() == fb {
+			// nothing changed, some platforms report the size right after showing the window
+			return
+		}
+		fb = r.Size()
+		w.sendSize(r)
+		if w.virtual == (image.Point{}) {
+			w.eventsIn <- gui.Resize{Rectangle: image.Rectangle{Max: w.guiSize(fb)}}
+		}
+	})
+
+	// Moving to a monitor with another pixel density may keep the size of the framebuffer,
+	// the Open GL thread still gets to update the viewport and call the OnResize callbacks.
+	w.w.SetContentScaleCallback(func(_ *glfw.Window, _, _ float32) {
+		w.sendSize(image.Rectangle{Max: fb})
 	})
 
 	w.w.SetCloseCallback(func(_ *glfw.Window) {
-		w.eventsIn <- WiClose{}
+		// the app decides whether to close, see ConfirmClose
+		w.w.SetShouldClose(false)
+		w.eventsIn <- WiClose{CloseReasonUser}
+	})
+
+	w.w.SetRefreshCallback(func(_ *glfw.Window) {
+		w.RequestRedraw()
+		w.eventsIn <- WiRefresh{}
+	})
+
+	w.unfocused.Store(w.w.GetAttrib(glfw.Focused) == glfw.False)
+	w.w.SetFocusCallback(func(_ *glfw.Window, focused bool) {
+		w.unfocused.Store(!focused)
+	})
+
+	w.w.SetPosCallback(func(_ *glfw.Window, x, y int) {
+		w.eventsIn <- WiMove{image.Pt(x, y)}
+	})
+
+	w.w.SetIconifyCallback(func(_ *glfw.Window, iconified bool) {
+		if iconified {
+			w.eventsIn <- WiMinimize{}
+		} else {
+			// nothing got composited while minimized, see openGLRenderGui
+			w.RequestRedraw()
+			w.eventsIn <- WiRestore{}
+		}
 	})
 
+	w.w.SetMaximizeCallback(func(_ *glfw.Window, maximized bool) {
+		if maximized {
+			w.eventsIn <- WiMaximize{}
+		} else {
+			w.eventsIn <- WiRestore{}
+		}
+	})
+
+	glfw.SetJoystickCallback(func(joy glfw.Joystick, event glfw.PeripheralEvent) {
+		switch event {
+		case glfw.Connected:
+			w.eventsIn <- JoyConnect{int(joy)}
+		case glfw.Disconnected:
+			w.eventsIn <- JoyDisconnect{int(joy)}
+		}
+	})
+
+	// Send exactly one initial Resize, with the size the framebuffer ended up with after the
+	// hiDPI handling, which isn't the size of the gui image if e.g. the OS clamped it.
 	r := w.img.Bounds()
+	if w.virtual == (image.Point{}) && r.Size() != w.guiSize(fb) {
+		r = image.Rectangle{Max: w.guiSize(fb)}
+		w.newSize <- image.Rectangle{Max: fb} // no callback ran yet, so there's room
+	}
 	w.eventsIn <- gui.Resize{Rectangle: r}
 
-	for {
+	w.eventLoop()
+}
+
+// eventLoop waits for events for a while and then queues itself on the main thread again.
+// That gives way to functions queued with mainthread.Call in between, so they can safely
+// call glfw functions that must be called on the main thread.
+func (w *Win) eventLoop() {
+	select {
+	case <-w.finish:
+		close(w.eventsIn)
+		if w.shortcutCalls != nil {
+			close(w.shortcutCalls)
+		}
+		w.w.Destroy()
+		w.destroyCursors()
+		return
+	default:
+	}
+	glfw.WaitEventsTimeout(1.0 / 30)
+	// gamepads can't wake up the loop, so they're polled at least 30 times a second
+	w.pollGamepads()
+	go mainthread.CallNonBlock(w.eventLoop)
+}
+
+// sendSize sends the new size of the framebuffer over to the Open GL thread. It never blocks
+// the main thread on a busy Open GL thread, only the latest size matters. It must be called
+// on the main thread.
+func (w *Win) sendSize(r image.Rectangle) {
+	select {
+	case w.newSize <- r:
+	default:
 		select {
-		case <-w.finish:
-			close(w.eventsIn)
-			w.w.Destroy()
-			return
+		case <-w.newSize:
 		default:
-			glfw.WaitEventsTimeout(1.0 / 30)
 		}
+		w.newSize <- r // only the main thread sends, so there's room now
 	}
 }
 
-func (w *Win) openGLThread() {
+// callMain runs f on the main thread, unless the window is closed, and waits for it to
+// return. It returns false if the window is closed and f didn't run.
+func (w *Win) callMain(f func()) bool {
+	ok := false
+	callMain(func() {
+		// the window gets destroyed on the main thread too, after finish gets closed
+		select {
+		case <-w.finish:
+		default:
+			f()
+			ok = true
+		}
+	})
+	return ok
+}
+
+// callMain runs f on the main thread and waits for it to return. It wakes up the event loop,
+// so f doesn't have to wait until it times out.
+func callMain(f func()) {
+	glfw.PostEmptyEvent()
+	mainthread.Call(f)
+}
+
+// openGLThread runs the Open GL side of the window. It reports the result of the setup to
+// setupErr and returns right away if the setup failed.
+func (w *Win) openGLThread(setupErr chan<- error) {
 	w.w.MakeContextCurrent()
 
-	w.openGLSetup()
+	if err := w.openGLSetup(); err != nil {
+		w.openGLCleanup()
+		glfw.DetachCurrentContext()
+		setupErr <- err
+		return
+	}
+	setupErr <- nil
+
+	w.openGLRenderGui(dirtyRects{w.img.Bounds()})
+	w.swapBuffers()
 
-	w.openGLRenderGui(w.img.Bounds())
-	w.w.SwapBuffers()
+	// flush fires once no new work arrived for a short while, that's when we upload all
+	// the changes to the screen at once. It's nil while there is nothing to flush.
+	var flush <-chan time.Time
 
-loop:
 	for {
-		var totalR image.Rectangle
-
 		select {
+		case <-flush:
+			if w.callOnFrame() {
+				w.presentFrame()
+			} else {
+				w.present()
+			}
+			w.dirty = w.dirty[:0]
+			flush = nil
+			if len(w.onFrame) > 0 {
+				// keep the frames coming for the OnFrame callbacks
+				flush = time.After(time.Until(w.lastFrame.Add(w.nextFrameInterval())))
+			}
+			continue
 		case r := <-w.newSize:
-			img := image.NewRGBA(r)
-			draw.Draw(img, w.img.Bounds(), w.img, w.img.Bounds().Min, draw.Src)
-			w.img = img
-			totalR = totalR.Union(r)
-			// update gui texture size
-			gl.DeleteTextures(1, &w.guiTexture)
-			width, height := img.Bounds().Dx(), img.Bounds().Dy()
-			w.guiTexture = newScreenTexture(width, height)
-			gl.Viewport(0, 0, int32(width), int32(height))
+			w.dirty.add(w.resize(r))
 		case d, ok := <-w.draw:
 			if !ok {
+				w.openGLCleanup()
 				close(w.finish)
 				return
 			}
-			r := d(w.img)
-			totalR = totalR.Union(r)
+			if !w.noGui {
+				w.queue = append(w.queue, zDraw{0, d})
+			}
+		case zd := <-w.drawZ:
+			if !w.noGui {
+				w.queue = append(w.queue, zd)
+			}
+		case <-w.redraw:
+			w.dirty.add(w.img.Bounds())
+		case ld := <-w.layerDraws:
+			w.resizePending()
+			if !w.noGui {
+				w.dirty.add(ld.d(ld.l.img))
+			}
+		case f := <-w.glCalls:
+			f()
+			idle := len(w.queue) == 0 && len(w.dirty) == 0
+			if idle && (flush != nil || len(w.onFrame) == 0) {
+				// nothing new to flush, e.g. just a query
+				continue
+			}
+		case <-w.confirmClose:
+			w.openGLCleanup()
+			close(w.finish)
+			go w.drainAfterClose(w.drawGL)
+			return
 		// just immediately run GL rendering
 		// we know all internal gl stuff is initialized
 		// TODO: ceck what we need to reset in internal flush to be able to render correctly
 		case glFunc, ok := <-w.drawGL:
 			if !ok {
+				w.openGLCleanup()
 				close(w.finish)
+				go w.drainAfterClose(nil)
 				return
 			}
+			w.bindTarget()
 			glFunc()
 			// for now rerender the gui each GL() call
-			w.openGLRenderGui(totalR)
-			w.w.SwapBuffers()
+			w.present()
 		}
-		for {
-			select {
-			case <-time.After(time.Second / 960):
-				w.openGLRenderGui(totalR)
-				w.w.SwapBuffers()
-				totalR = image.ZR
-				continue loop
-			case r := <-w.newSize:
-				img := image.NewRGBA(r)
-				draw.Draw(img, w.img.Bounds(), w.img, w.img.Bounds().Min, draw.Src)
-				w.img = img
-				totalR = totalR.Union(r)
-				// update gui texture size
-				gl.DeleteTextures(1, &w.guiTexture)
-				width, height := img.Bounds().Dx(), img.Bounds().Dy()
-				w.guiTexture = newScreenTexture(width, height)
-			    gl.Viewport(0, 0, int32(width), int32(height))
-			case d, ok := <-w.draw:
-				if !ok {
-					close(w.finish)
-					return
-				}
-				r := d(w.img)
-				totalR = totalR.Union(r)
-			// just immediately run GL rendering
-			// we know all internal gl stuff is initialized
-			// TODO: ceck what we need to reset in internal flush to be able to render correctly
-			case glFunc, ok := <-w.drawGL:
-				if !ok {
-					close(w.finish)
-					return
-				}
-				glFunc()
-				// for now rerender the gui each GL() call
-				w.openGLRenderGui(totalR)
-				w.w.SwapBuffers()
+		flush = time.After(w.flushDelay)
+	}
+}
+
+// drainAfterClose keeps receiving from the Draw() channel, and from drawGL unless it's nil,
+// after the window closed without Draw() getting closed, e.g. with ConfirmClose. It drops
+// the functions, so goroutines still drawing don't block forever. It stops once Draw() gets
+// closed.
+func (w *Win) drainAfterClose(drawGL <-chan func()) {
+	for {
+		select {
+		case _, ok := <-w.draw:
+			if !ok {
+				return
+			}
+		case _, ok := <-drawGL:
+			if !ok {
+				drawGL = nil
 			}
 		}
 	}
 }
 
+// unfocusedFrameInterval is the time between the frames for the OnFrame callbacks while the
+// window doesn't have the focus, see ProcessWhenUnfocused.
+const unfocusedFrameInterval = time.Second / 10
+
+// nextFrameInterval returns the time until the next frame for the OnFrame callbacks.
+func (w *Win) nextFrameInterval() time.Duration {
+	if w.unfocused.Load() && !w.whenUnfocused {
+		return max(w.frameInterval, unfocusedFrameInterval)
+	}
+	return w.frameInterval
+}
+
+// present applies the queued drawing functions and puts the changed part of the gui over
+// the Open GL content on the screen.
+func (w *Win) present() {
+	w.applyDraws()
+	w.openGLRenderGui(w.dirty)
+	w.swapBuffers()
+	w.frames.frame(time.Now())
+}
+
+// presentFrame is like present, but for a frame of the OnFrame callbacks, which rendered
+// all of the Open GL content anew onto the back buffer. Only the changed part of the gui
+// gets uploaded, but all of it gets composited over the new content, once, and then it goes
+// on the screen with a single swap.
+func (w *Win) presentFrame() {
+	w.applyDraws()
+	w.renderGui(w.dirty, dirtyRects{w.img.Bounds()}, false)
+	w.swapBuffers()
+	w.frames.frame(time.Now())
+}
+
+// resize replaces the gui image with one of the new size of the framebuffer r, keeping the
+// old content, and reallocates the gui texture to match. With a virtual resolution, the gui
+// image stays and just gets placed in the new framebuffer, with a GUIScale it gets the
+// scaled down size. It returns the part of the gui image that needs
+// to be redrawn on the screen. The OnResize callbacks run last.
+func (w *Win) resize(r image.Rectangle) image.Rectangle {
+	if r.Empty() {
+		// a minimized window, nothing to resize to
+		return image.Rectangle{}
+	}
+	defer func() {
+		for _, f := range w.onResize {
+			f(r.Dx(), r.Dy())
+		}
+	}()
+
+	if w.offscreenBuf != nil && w.offscreenBuf.size != r.Size() {
+		if err := w.offscreenBuf.resize(r.Size()); err != nil {
+			logf(LogError, "%v", err)
+		}
+		w.bindTarget()
+	}
+
+	w.view, _ = w.guiView(r.Size())
+	gr := image.Rectangle{Max: w.guiSize(r.Size())}
+	if w.noGui {
+		w.img.Rect = gr
+		for _, l := range w.layers {
+			l.img.Rect = gr
+		}
+		gl.Viewport(0, 0, int32(r.Dx()), int32(r.Dy()))
+		return gr
+	}
+	if w.virtual != (image.Point{}) {
+		gl.Viewport(0, 0, int32(r.Dx()), int32(r.Dy()))
+		return w.img.Bounds()
+	}
+	img := resizeRGBA(w.img, gr)
+	w.img = img
+	for _, l := range w.layers {
+		l.img = resizeRGBA(l.img, gr)
+	}
+	// the gui texture only gets reallocated when it's too small, with some room to grow, so
+	// a live resize doesn't reallocate it on every step
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	if width > w.texSize.X || height > w.texSize.Y {
+		gl.DeleteTextures(1, &w.guiTexture)
+		w.texSize = image.Pt(max(w.texSize.X, textureCapacity(width)), max(w.texSize.Y, textureCapacity(height)))
+		var maxSize int32
+		gl.GetIntegerv(gl.MAX_TEXTURE_SIZE, &maxSize)
+		if w.texSize.X > int(maxSize) || w.texSize.Y > int(maxSize) {
+			logf(LogError, "gui of %dx%d exceeds the maximum texture size %d", width, height, maxSize)
+		}
+		w.guiTexture = newScreenTexture(w.texSize.X, w.texSize.Y, w.guiFilter)
+	}
+	gl.Viewport(0, 0, int32(r.Dx()), int32(r.Dy()))
+	return gr
+}
+
+// textureCapacity rounds the size n of the gui up to the size to allocate for the texture.
+func textureCapacity(n int) int {
+	const step = 256
+	return (n + step - 1) / step * step
+}
+
+// swapBuffers puts what got rendered on the screen. Single buffered, everything already got
+// rendered onto the screen, so it only makes sure it all gets done.
+func (w *Win) swapBuffers() {
+	if w.singleBuffer {
+		gl.Flush()
+		return
+	}
+	w.w.SwapBuffers()
+}
+
+// resizePending handles a resize that's waiting in the channel, if any. The size gets sent
+// before the gui.Resize event, so this makes sure that drawing functions sent in response
+// to the event never draw onto the old image.
+func (w *Win) resizePending() {
+	select {
+	case r := <-w.newSize:
+		w.dirty.add(w.resize(r))
+	default:
+	}
+}
+
+// applyDraws runs all the queued drawing functions in ascending z order and marks the
+// rectangles they changed as dirty.
+func (w *Win) applyDraws() {
+	w.resizePending()
+	sort.SliceStable(w.queue, func(i, j int) bool { return w.queue[i].z < w.queue[j].z })
+	for _, zd := range w.queue {
+		w.dirty.add(zd.d(w.img))
+	}
+	w.queue = w.queue[:0]
+}
+
 
 // For now the we render using a transparent texture where the whole gui gets rendered and we scissor and
 // only clear the depth buffer to keep other stuff on both buffers.
@@ -389,69 +1702,161 @@ loop:
 //   with open gl scissor. We should save the area and when renderGui is executed we clear just the depth bit.
 //
 
-func (w *Win) openGLRenderGui(r image.Rectangle) {
+// openGLRenderGui uploads the changed parts rects of the gui and composites them over the
+// Open GL content of both buffers, swapping after each.
+func (w *Win) openGLRenderGui(rects dirtyRects) {
+	w.renderGui(rects, rects, true)
+}
+
+// renderGui uploads the parts uploads of the gui to its texture and composites the parts
+// composites of it over the Open GL content. With both, it does so onto both buffers,
+// swapping after each, otherwise just onto the one being rendered, leaving the swap to the
+// caller.
+func (w *Win) renderGui(uploads, composites dirtyRects, both bool) {
+	if w.noGui {
+		return
+	}
 
 	bounds := w.img.Bounds()
-	r = r.Intersect(bounds)
-	if r.Empty() {
+	clip := func(rects dirtyRects) []image.Rectangle {
+		var rs []image.Rectangle
+		for _, r := range rects {
+			if r = r.Intersect(bounds); !r.Empty() {
+				rs = append(rs, r)
+			}
+		}
+		return rs
+	}
+	us, rs := clip(uploads), clip(composites)
+	if len(us) == 0 && len(rs) == 0 {
 		return
 	}
 
-	tmp := image.NewRGBA(r)
-	draw.Draw(tmp, r, w.img, r.Min, draw.Src)
+	// leave the state of the Open GL functions as they set it
+	state := saveGLState()
+	defer state.restore()
+	gl.BindFramebuffer(gl.FRAMEBUFFER, w.renderTarget())
 
 	gl.UseProgram(w.guiShader)
+	gl.Uniform2f(w.texScaleUniform, float32(bounds.Dx())/float32(w.texSize.X), float32(bounds.Dy())/float32(w.texSize.Y))
+	// the texture may be bigger than the gui, so clamping to its edge isn't enough to keep
+	// linear filtering from blending in the texels beyond the gui
+	gl.Uniform2f(w.texMaxUniform, (float32(bounds.Dx())-0.5)/float32(w.texSize.X), (float32(bounds.Dy())-0.5)/float32(w.texSize.Y))
 	gl.Enable(gl.BLEND)
-	gl.BlendFunc(gl.ONE, gl.ONE_MINUS_SRC_ALPHA)  		 // Assume premultiplied alpha
-	//gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA) // Non-premultipled version
+	// The pixels of an *image.RGBA are alpha-premultiplied, whatever gets drawn onto it with
+	// the image/draw package ends up premultiplied, so the texture is premultiplied too.
+	// This also composites the alpha channel correctly, so the transparent parts of the gui
+	// leave a transparent framebuffer transparent.
+	gl.BlendFunc(gl.ONE, gl.ONE_MINUS_SRC_ALPHA)
 	//gl.Clear(gl.DEPTH_BUFFER_BIT | gl.COLOR_BUFFER_BIT)
 
-	gl.TextureSubImage2D(
-		w.guiTexture,
-		0,
-		int32(r.Min.X),
-		int32(r.Min.Y),
-		int32(r.Dx()),
-		int32(r.Dy()),
-		gl.RGBA,
-		gl.UNSIGNED_BYTE,
-		gl.Ptr(tmp.Pix))
+	// not TextureSubImage2D, which needs Open GL 4.5 (or the direct state access extension)
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindTexture(gl.TEXTURE_2D, w.guiTexture)
+	if float64(area(us)) > w.fullUpload*float64(bounds.Dx()*bounds.Dy()) {
+		us = []image.Rectangle{bounds}
+	}
+	for _, r := range us {
+		if w.pixelBuffers && w.uploadPixelBuffer(r) {
+			continue
+		}
+		var pix []uint8
+		if r == bounds && len(w.layers) == 0 && w.img.Stride == 4*bounds.Dx() {
+			// nothing to composite, so the gui can go up without a copy
+			pix = w.img.Pix
+		} else {
+			pix = w.composite(r).Pix
+		}
+		gl.BindBuffer(gl.PIXEL_UNPACK_BUFFER, 0)
+		gl.TexSubImage2D(
+			gl.TEXTURE_2D,
+			0,
+			int32(r.Min.X),
+			int32(r.Min.Y),
+			int32(r.Dx()),
+			int32(r.Dy()),
+			gl.RGBA,
+			gl.UNSIGNED_BYTE,
+			gl.Ptr(pix))
+	}
 
 	gl.Enable(gl.DEPTH_TEST)
+	gl.DepthMask(true)
 	gl.DepthFunc(gl.LESS)
 
-	// TODO: might be wrong, need to add ceil/floor to the values.
-	// TODO: scissor array of rects?
-	_, hei := w.w.GetFramebufferSize()
-	gl.Enable(gl.SCISSOR_TEST)
-	gl.Scissor(int32(r.Min.X), int32(hei) - int32(r.Max.Y), int32(r.Dx()), int32(r.Dy()))
+	// leave the stencil buffer to the Open GL functions
+	gl.Disable(gl.STENCIL_TEST)
 
-	gl.ActiveTexture(gl.TEXTURE0)
-	gl.BindTexture(gl.TEXTURE_2D, w.guiTexture)
+	// the gui is sRGB already, see SRGB
+	gl.Disable(gl.FRAMEBUFFER_SRGB)
+
+	wid, hei := w.w.GetFramebufferSize()
+	if wid == 0 || hei == 0 {
+		// minimized, the texture is up to date for when the window gets restored
+		return
+	}
+	gl.Enable(gl.SCISSOR_TEST)
+	gl.Viewport(int32(w.view.Min.X), int32(hei-w.view.Max.Y), int32(w.view.Dx()), int32(w.view.Dy()))
+	gl.BindVertexArray(w.quadVao)
 
 	//TODO: this is a dirty trick to draw the gui on both buffers
 	//      double render and we are on the same buffer as before.
-	for range 2 {
-		gl.Clear(gl.DEPTH_BUFFER_BIT)
-		gl.BindVertexArray(w.quadVao)
-		gl.DrawArrays(gl.TRIANGLES, 0, 6*2*3)
-
-		w.w.SwapBuffers()
+	passes := 1
+	if both && !w.singleBuffer {
+		passes = 2
 	}
+	for range passes {
+		w.clearLetterbox(image.Pt(wid, hei))
+		for _, r := range rs {
+			fr := w.toFramebuffer(r)
+			gl.Scissor(int32(fr.Min.X), int32(hei)-int32(fr.Max.Y), int32(fr.Dx()), int32(fr.Dy()))
+			gl.Clear(gl.DEPTH_BUFFER_BIT)
+			gl.DrawArrays(gl.TRIANGLES, 0, 6*2*3)
+		}
 
-	gl.Disable(gl.BLEND)
-	gl.Disable(gl.SCISSOR_TEST)
-	gl.Disable(gl.DEPTH_TEST)
+		if both && !w.singleBuffer {
+			w.w.SwapBuffers()
+		}
+	}
 }
 
-func (w *Win) openGLSetup() {
+func (w *Win) openGLSetup() error {
 	var err error
 	if err = gl.Init(); err != nil {
-		panic(err)
+		return fmt.Errorf("failed to initialize open gl: %v", err)
+	}
+
+	if w.transparent {
+		setClearColor(color.Transparent)
+	} else {
+		setClearColor(color.Black)
+	}
+
+	if w.samples > 0 {
+		gl.Enable(gl.MULTISAMPLE)
+	}
+
+	if w.srgb {
+		gl.Enable(gl.FRAMEBUFFER_SRGB)
+	}
+
+	if w.swap != nil {
+		setSwapMode(*w.swap)
+	}
+
+	if w.offscreen {
+		wid, hei := w.w.GetFramebufferSize()
+		if w.offscreenBuf, err = newOffscreenBuffer(image.Pt(wid, hei)); err != nil {
+			return err
+		}
+	}
+
+	if w.noGui {
+		return nil
 	}
 
 	var screenVertShader = `
-		#version 420
+		` + w.glslVersion + `
 
 		in vec3 vert;
 		in vec2 vertTexCoord;
@@ -464,15 +1869,17 @@ func (w *Win) openGLSetup() {
 	` + "\x00"
 
 	var screenFragShader = `
-		#version 420
+		` + w.glslVersion + `
 
 		uniform sampler2D tex;
+		uniform vec2 texScale; // the part of the texture the gui covers
+		uniform vec2 texMax;   // the center of the last texel of the gui
 		in vec2 fragTexCoord;
 
 		out vec4 outputColor;
 
 		void main() {
-			outputColor = texture(tex, fragTexCoord);
+			outputColor = texture(tex, min(fragTexCoord * texScale, texMax));
 		}
 	` + "\x00"
 
@@ -487,20 +1894,26 @@ func (w *Win) openGLSetup() {
 	}
 
 	w.guiShader, err = NewGLProgram(screenVertShader, screenFragShader)
+	if err != nil {
+		return err
+	}
 	//gl.UseProgram(w.guiShader)
 
 	wid, hei := w.w.GetFramebufferSize()
-	w.guiTexture = newScreenTexture(wid, hei)
+	w.view, _ = w.guiView(image.Pt(wid, hei))
+	w.texSize = w.img.Bounds().Size()
+	w.guiTexture = newScreenTexture(w.texSize.X, w.texSize.Y, w.guiFilter)
 	textureUniform := gl.GetUniformLocation(w.guiShader, gl.Str("tex\x00"))
 	gl.Uniform1i(textureUniform, 0)
+	w.texScaleUniform = gl.GetUniformLocation(w.guiShader, gl.Str("texScale\x00"))
+	w.texMaxUniform = gl.GetUniformLocation(w.guiShader, gl.Str("texMax\x00"))
 	gl.BindFragDataLocation(w.guiShader, 0, gl.Str("outputColor\x00"))
 
 	gl.GenVertexArrays(1, &w.quadVao)
 	gl.BindVertexArray(w.quadVao)
 
-	var vbo uint32
-	gl.GenBuffers(1, &vbo)
-	gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
+	gl.GenBuffers(1, &w.quadVbo)
+	gl.BindBuffer(gl.ARRAY_BUFFER, w.quadVbo)
 	gl.BufferData(gl.ARRAY_BUFFER, len(quadVertices)*4, gl.Ptr(quadVertices), gl.STATIC_DRAW)
 
 	vertAttrib := uint32(gl.GetAttribLocation(w.guiShader, gl.Str("vert\x00")))
@@ -511,10 +1924,40 @@ func (w *Win) openGLSetup() {
 	gl.EnableVertexAttribArray(texCoordAttrib)
 	gl.VertexAttribPointerWithOffset(texCoordAttrib, 2, gl.FLOAT, false, 5*4, 3*4)
 
-	gl.ClearColor(1.0, 1.0, 0.0, 1.0)
+	if w.pixelBuffers {
+		gl.GenBuffers(int32(len(w.pbos)), &w.pbos[0])
+	}
+
+	return nil
 }
 
 
+// glslVersion returns the version directive for the internal shaders that works with the
+// given Open GL context version.
+func glslVersion(major, minor int) string {
+	if major > 4 || major == 4 && minor >= 2 {
+		return "#version 420"
+	}
+	return "#version 330"
+}
+
+// openGLCleanup deletes the Open GL objects of the gui. It runs on the Open GL thread right
+// before it exits, so windows that come and go don't leak GPU memory. Deleting the zero
+// objects left by a failed setup or NoGui is a no-op.
+func (w *Win) openGLCleanup() {
+	gl.DeleteVertexArrays(1, &w.quadVao)
+	gl.DeleteBuffers(1, &w.quadVbo)
+	gl.DeleteTextures(1, &w.guiTexture)
+	gl.DeleteProgram(w.guiShader)
+	gl.DeleteBuffers(int32(len(w.pbos)), &w.pbos[0])
+	if w.offscreenBuf != nil {
+		w.offscreenBuf.delete()
+		w.offscreenBuf = nil
+	}
+	w.quadVao, w.quadVbo, w.guiTexture, w.guiShader = 0, 0, 0, 0
+	w.pbos = [pixelBufferCount]uint32{}
+}
+
 func NewGLProgram(vertexShaderSource, fragmentShaderSource string) (uint32, error) {
 
 	vertexShader, err := compileShader(vertexShaderSource, gl.VERTEX_SHADER)
@@ -551,6 +1994,45 @@ func NewGLProgram(vertexShaderSource, fragmentShaderSource string) (uint32, erro
 	return program, nil
 }
 
+// NewGLProgramFromFiles is like NewGLProgram, but reads the shader sources from the given
+// files. Next to the program, it returns a function that reads the files again and builds
+// a new program from them, which is handy to reload the shaders during development, e.g.
+// when a file watcher fires.
+//
+// The reload function deletes the previous program and returns the new one. If reading or
+// compiling fails, it returns the error and the previous program stays untouched and usable.
+// Both NewGLProgramFromFiles and the reload function must be called on the Open GL thread,
+// i.e. from a function sent to the GL() channel.
+func NewGLProgramFromFiles(vertPath, fragPath string) (uint32, func() (uint32, error), error) {
+	load := func() (uint32, error) {
+		vert, err := os.ReadFile(vertPath)
+		if err != nil {
+			return 0, err
+		}
+		frag, err := os.ReadFile(fragPath)
+		if err != nil {
+			return 0, err
+		}
+		return NewGLProgram(string(vert)+"\x00", string(frag)+"\x00")
+	}
+
+	program, err := load()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	reload := func() (uint32, error) {
+		newProgram, err := load()
+		if err != nil {
+			return 0, err
+		}
+		gl.DeleteProgram(program)
+		program = newProgram
+		return program, nil
+	}
+	return program, reload, nil
+}
+
 func compileShader(source string, shaderType uint32) (uint32, error) {
 	shader := gl.CreateShader(shaderType)
 	csources, free := gl.Strs(source)
@@ -574,7 +2056,9 @@ func compileShader(source string, shaderType uint32) (uint32, error) {
 	return shader, nil
 }
 
-func newScreenTexture(width, height int) (uint32) {
+// newScreenTexture creates a transparent texture of the given size, filtered with filter,
+// gl.LINEAR or gl.NEAREST.
+func newScreenTexture(width, height int, filter int32) (uint32) {
 
 	rgba := image.NewRGBA(image.Rect(0, 0, width, height))
 	if rgba.Stride != rgba.Rect.Size().X*4 {
@@ -586,8 +2070,8 @@ func newScreenTexture(width, height int) (uint32) {
 	gl.GenTextures(1, &texture)
 	gl.ActiveTexture(gl.TEXTURE0)
 	gl.BindTexture(gl.TEXTURE_2D, texture)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, filter)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, filter)
 	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
 	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
 	gl.TexImage2D(
@@ -602,4 +2086,4 @@ func newScreenTexture(width, height int) (uint32) {
 		gl.Ptr(rgba.Pix))
 
 	return texture
-}
\ No newline at end of file
+}
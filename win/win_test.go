@@ -0,0 +1,107 @@
+package win
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"testing"
+)
+
+// blendOver is what gl.BlendFunc(gl.ONE, gl.ONE_MINUS_SRC_ALPHA) computes for the gui pixel
+// src over the Open GL content dst.
+func blendOver(src, dst color.RGBA) color.RGBA {
+	over := func(s, d uint8) uint8 {
+		return uint8(uint32(s) + uint32(d)*(0xff-uint32(src.A))/0xff)
+	}
+	return color.RGBA{over(src.R, dst.R), over(src.G, dst.G), over(src.B, dst.B), over(src.A, dst.A)}
+}
+
+func TestCompositeSemiTransparent(t *testing.T) {
+	background := color.RGBA{0, 0, 0xff, 0xff}
+	tests := []struct {
+		name string
+		c    color.Color
+		want color.RGBA
+	}{
+		{"straight alpha", color.NRGBA{0xff, 0, 0, 0x80}, color.RGBA{0x80, 0, 0x7f, 0xff}},
+		{"premultiplied", color.RGBA{0x80, 0, 0, 0x80}, color.RGBA{0x80, 0, 0x7f, 0xff}},
+		{"opaque", color.RGBA{0xff, 0, 0, 0xff}, color.RGBA{0xff, 0, 0, 0xff}},
+		{"transparent", color.RGBA{}, background},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &Win{img: image.NewRGBA(image.Rect(0, 0, 4, 4))}
+			r := FillRect(w.img, image.Rect(1, 1, 3, 3), tt.c)
+
+			// the pixels that get uploaded to the gui texture
+			uploaded := w.composite(r)
+			got := blendOver(uploaded.RGBAAt(2, 2), background)
+			if !near(got, tt.want) {
+				t.Errorf("composited %v over %v = %v, want %v", tt.c, background, got, tt.want)
+			}
+		})
+	}
+}
+
+// near tells whether the colors differ by at most 1 in each channel, the rounding error of
+// 8 bit blending.
+func near(a, b color.RGBA) bool {
+	d := func(x, y uint8) bool { return abs(int(x)-int(y)) <= 1 }
+	return d(a.R, b.R) && d(a.G, b.G) && d(a.B, b.B) && d(a.A, b.A)
+}
+
+func TestResizeZero(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 64, 48))
+	resized := false
+	w := &Win{
+		img:      img,
+		texSize:  image.Pt(256, 256),
+		onResize: []func(width, height int){func(int, int) { resized = true }},
+	}
+
+	// a minimized window on Windows, nothing may touch Open GL
+	if r := w.resize(image.Rectangle{}); !r.Empty() {
+		t.Errorf("resize to 0x0 returned %v, want an empty rectangle", r)
+	}
+	if w.img != img || w.img.Bounds() != image.Rect(0, 0, 64, 48) {
+		t.Errorf("resize to 0x0 replaced the gui image with one of %v", w.img.Bounds())
+	}
+	if w.texSize != image.Pt(256, 256) {
+		t.Errorf("resize to 0x0 changed the texture size to %v", w.texSize)
+	}
+	if resized {
+		t.Error("resize to 0x0 called the OnResize callbacks")
+	}
+}
+
+// BenchmarkUpload compares the cost on the CPU of uploading the changed part of a 1920x1080
+// gui, spread over 4 rectangles, with the cost of uploading all of it, see FullUpload. The
+// changed parts get composited into a buffer of their own first, the whole gui goes up
+// without a copy. The copy into staging stands in for the driver copying the pixels.
+func BenchmarkUpload(b *testing.B) {
+	w := &Win{img: image.NewRGBA(image.Rect(0, 0, 1920, 1080))}
+	bounds := w.img.Bounds()
+	staging := make([]uint8, len(w.img.Pix))
+
+	b.Run("full", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			copy(staging, w.img.Pix)
+		}
+	})
+	for _, fraction := range []float64{0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0.8, 1} {
+		// 4 strips, spread over the gui
+		var rs []image.Rectangle
+		strip := int(fraction * float64(bounds.Dy()) / 4)
+		for j := 0; j < 4; j++ {
+			y := j * bounds.Dy() / 4
+			rs = append(rs, image.Rect(0, y, bounds.Dx(), y+strip))
+		}
+		b.Run(fmt.Sprintf("partial-%g", fraction), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				for _, r := range rs {
+					copy(staging, w.composite(r).Pix)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+// Package wintest provides a fake environment for testing gui code without a window or an
+// Open GL context.
+package wintest
+
+import (
+	"image"
+	"image/draw"
+
+	"github.com/bbeni/guiGL"
+)
+
+// Env is an in-memory implementation of gui.Env. Drawing functions sent to it draw onto an
+// image that can be inspected with Image, and the rectangles they return get recorded, see
+// Rects. Events get pushed with Send.
+//
+// Env has no Open GL context, so the functions sent to the GL channel only get counted,
+// not called, see GLCalls.
+//
+// Everything happens on a single goroutine, in the order it was sent. So after a drawing
+// function got sent, Image and Rects already see what it did. Closing the Draw channel
+// closes the Env and its Events channel, like closing a window.
+type Env struct {
+	eventsOut <-chan gui.Event
+	eventsIn  chan<- gui.Event
+	draw      chan func(draw.Image) image.Rectangle
+	drawGL    chan func()
+	calls     chan func()
+	finish    chan struct{}
+
+	img     *image.RGBA
+	rects   []image.Rectangle
+	glCalls int
+}
+
+var _ gui.Env = (*Env)(nil)
+
+// New creates an Env with a drawing area of the given size, which starts out transparent.
+// Like a window, it sends a Resize event with the bounds of the drawing area first.
+func New(width, height int) *Env {
+	eventsOut, eventsIn := gui.MakeEventsChan()
+	e := &Env{
+		eventsOut: eventsOut,
+		eventsIn:  eventsIn,
+		draw:      make(chan func(draw.Image) image.Rectangle),
+		drawGL:    make(chan func()),
+		calls:     make(chan func()),
+		finish:    make(chan struct{}),
+		img:       image.NewRGBA(image.Rect(0, 0, width, height)),
+	}
+	eventsIn <- gui.Resize{Rectangle: e.img.Bounds()}
+	go e.loop()
+	return e
+}
+
+// Events returns the events channel of the Env.
+func (e *Env) Events() <-chan gui.Event { return e.eventsOut }
+
+// Draw returns the draw channel of the Env.
+func (e *Env) Draw() chan<- func(draw.Image) image.Rectangle { return e.draw }
+
+// GL returns the Open GL channel of the Env.
+func (e *Env) GL() chan<- func() { return e.drawGL }
+
+func (e *Env) loop() {
+	defer func() {
+		close(e.eventsIn)
+		close(e.finish)
+	}()
+	drawGL := e.drawGL
+	for {
+		select {
+		case d, ok := <-e.draw:
+			if !ok {
+				return
+			}
+			e.rects = append(e.rects, d(e.img))
+		case _, ok := <-drawGL:
+			if !ok {
+				// no more Open GL calls, but keep drawing
+				drawGL = nil
+				continue
+			}
+			e.glCalls++
+		case f := <-e.calls:
+			f()
+		}
+	}
+}
+
+// call runs f on the goroutine of the Env, after everything sent to it before. It reports
+// false without calling f if the Env is closed.
+func (e *Env) call(f func()) bool {
+	done := make(chan struct{})
+	select {
+	case e.calls <- func() { f(); close(done) }:
+	case <-e.finish:
+		return false
+	}
+	<-done
+	return true
+}
+
+// Send pushes the event to the Events channel, as if it happened in a window. It does
+// nothing if the Env is closed.
+func (e *Env) Send(ev gui.Event) {
+	e.call(func() { e.eventsIn <- ev })
+}
+
+// Resize changes the size of the drawing area and sends a Resize event with its new bounds.
+// The content is kept where the old and the new drawing area overlap, the rest is
+// transparent, like in a window. It does nothing if the Env is closed.
+func (e *Env) Resize(width, height int) {
+	e.call(func() {
+		img := image.NewRGBA(image.Rect(0, 0, width, height))
+		draw.Draw(img, img.Bounds(), e.img, image.Point{}, draw.Src)
+		e.img = img
+		e.eventsIn <- gui.Resize{Rectangle: img.Bounds()}
+	})
+}
+
+// Image returns a copy of the drawing area with everything drawn onto it so far. It also
+// works after the Env got closed, returning the last content.
+func (e *Env) Image() *image.RGBA {
+	var img *image.RGBA
+	copyImg := func() {
+		img = image.NewRGBA(e.img.Bounds())
+		copy(img.Pix, e.img.Pix)
+	}
+	if !e.call(copyImg) {
+		copyImg()
+	}
+	return img
+}
+
+// Rects returns the rectangles returned by the drawing functions so far, in the order they
+// were sent. It also works after the Env got closed.
+func (e *Env) Rects() []image.Rectangle {
+	var rects []image.Rectangle
+	copyRects := func() { rects = append(rects, e.rects...) }
+	if !e.call(copyRects) {
+		copyRects()
+	}
+	return rects
+}
+
+// GLCalls returns how many functions were sent to the GL channel so far. It also works
+// after the Env got closed.
+func (e *Env) GLCalls() int {
+	var n int
+	count := func() { n = e.glCalls }
+	if !e.call(count) {
+		count()
+	}
+	return n
+}
+
+// Closed returns a channel that gets closed after the Draw channel got closed and the Env
+// finished the drawing functions sent before.
+func (e *Env) Closed() <-chan struct{} { return e.finish }